@@ -0,0 +1,99 @@
+package elk
+
+import (
+	"fmt"
+	"io"
+)
+
+// Attr is a key/value pair attached to an Error
+// to provide additional context for debugging and
+// logging purposes.
+//
+// In contrast to details, attributes are never
+// exposed in an ErrorResponseModel.
+type Attr struct {
+	Key   string
+	Value any
+}
+
+func (t Attr) String() string {
+	return fmt.Sprintf("%s=%v", t.Key, t.Value)
+}
+
+// attrNode is an element of an immutable linked list
+// of attributes. New attributes are prepended so that
+// copies of an Error can share the same tail without
+// affecting each other.
+type attrNode struct {
+	attr Attr
+	next *attrNode
+}
+
+// list returns the attributes of the list in the
+// order they have been attached.
+func (t *attrNode) list() []Attr {
+	n := 0
+	for node := t; node != nil; node = node.next {
+		n++
+	}
+
+	attrs := make([]Attr, n)
+	for node := t; node != nil; node = node.next {
+		n--
+		attrs[n] = node.attr
+	}
+
+	return attrs
+}
+
+// With returns a copy of the error with the given
+// key/value attribute attached. The original error
+// is left unchanged.
+func (t Error) With(key string, value any) Error {
+	t.attrs = &attrNode{
+		attr: Attr{Key: key, Value: value},
+		next: t.attrs,
+	}
+	return t
+}
+
+// WithAttrs returns a copy of the error with all
+// given attributes attached. The original error
+// is left unchanged.
+func (t Error) WithAttrs(attrs ...Attr) Error {
+	for _, attr := range attrs {
+		t = t.With(attr.Key, attr.Value)
+	}
+	return t
+}
+
+// Attrs returns the attributes of the error and of
+// every Error in the chain of wrapped errors.
+//
+// Attributes of outer errors are listed before
+// attributes of inner errors. Attributes of the same
+// error are listed in the order they have been
+// attached.
+func (t Error) Attrs() []Attr {
+	var attrs []Attr
+
+	unwrapChain(t, func(err error) bool {
+		if e, ok := err.(Error); ok {
+			attrs = append(attrs, e.attrs.list()...)
+		}
+		return true
+	})
+
+	return attrs
+}
+
+func writeAttrs(w io.Writer, attrs []Attr) {
+	if len(attrs) == 0 {
+		return
+	}
+
+	fmt.Fprint(w, "attributes:\n")
+	for _, attr := range attrs {
+		fmt.Fprintf(w, "  %s\n", attr)
+	}
+}
@@ -0,0 +1,57 @@
+package elk
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/studio-b12/elk/internal/assert"
+)
+
+func TestAttrs(t *testing.T) {
+	const ErrCode = ErrorCode("some-error-code")
+
+	t.Run("none", func(t *testing.T) {
+		err := NewError(ErrCode)
+		assert.Equal(t, 0, len(err.Attrs()))
+	})
+
+	t.Run("immutable", func(t *testing.T) {
+		err := NewError(ErrCode).With("foo", 1)
+		errA := err.With("bar", 2)
+		errB := err.With("baz", 3)
+
+		assert.Equal(t, 1, len(err.Attrs()))
+		assert.Equal(t, "[foo=1 bar=2]", fmt.Sprint(errA.Attrs()))
+		assert.Equal(t, "[foo=1 baz=3]", fmt.Sprint(errB.Attrs()))
+	})
+
+	t.Run("chain", func(t *testing.T) {
+		var err error = NewError(ErrCode).With("id", 42)
+		err = fmt.Errorf("intermediate: %w", err)
+		err = Wrap(ErrCode, err, "some message").
+			WithAttrs(Attr{Key: "tenant", Value: "foo"}, Attr{Key: "retries", Value: 3})
+
+		attrs := Cast(err).Attrs()
+		assert.Equal(t, "[tenant=foo retries=3 id=42]", fmt.Sprint(attrs))
+	})
+
+	t.Run("format", func(t *testing.T) {
+		err := Wrap(ErrCode, NewError(ErrCode).With("id", 42), "some message").
+			With("tenant", "foo")
+
+		s := fmt.Sprintf("%+.0v", err)
+		assert.True(t, strings.Contains(s, "attributes:\n  tenant=foo\n  id=42\n"))
+
+		s = fmt.Sprintf("%#v", err)
+		assert.True(t, strings.Contains(s, "attributes:\n  tenant=foo\n"))
+		assert.True(t, strings.Contains(s, "attributes:\n  id=42\n"))
+	})
+
+	t.Run("response-model", func(t *testing.T) {
+		err := Wrap(ErrCode, errors.New("some error")).With("id", 42)
+		model := err.ToResponseModel(0)
+		assert.Equal(t, nil, model.Details)
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"runtime"
 	"strconv"
+	"sync"
 )
 
 // CallFrame is a type alias for runtime.Frame with additional formatting
@@ -18,25 +19,52 @@ func (t CallFrame) String() string {
 
 func (t CallFrame) Format(s fmt.State, verb rune) {
 	width, hasWidth := s.Width()
+	if !hasWidth {
+		width = -1
+	}
 
 	switch verb {
 	case 's':
-		format := "%s %s:%d"
-		if hasWidth {
-			format = "%-" + strconv.Itoa(width) + "s\t%s:%d"
-		}
-		fmt.Fprintf(s, format, t.Function, t.File, t.Line)
+		s.Write(t.appendTo(nil, width))
 	case 'v':
 		fmt.Fprintf(s, "%v", runtime.Frame(t))
 	}
 }
 
+// appendTo appends the frame formatted as with the %s verb
+// to b. When width is not negative, the function name is
+// padded to width and separated from the file by a tab.
+func (t CallFrame) appendTo(b []byte, width int) []byte {
+	b = append(b, t.Function...)
+
+	if width < 0 {
+		b = append(b, ' ')
+	} else {
+		for i := len(t.Function); i < width; i++ {
+			b = append(b, ' ')
+		}
+		b = append(b, '\t')
+	}
+
+	b = append(b, t.File...)
+	b = append(b, ':')
+	return strconv.AppendInt(b, int64(t.Line), 10)
+}
+
 // CallStack contains the list of called
 // runtime.Frames in the call chain with
 // an offset from which frames are
 // reported.
+//
+// A nil CallStack, as returned for Errors whose
+// call stack capture is disabled, is valid and
+// contains no frames.
+//
+// A CallStack is safe for concurrent use. The
+// frames are resolved once on first access.
 type CallStack struct {
 	ptrs   []uintptr
+	once   sync.Once
 	frames []CallFrame
 	offset int
 }
@@ -44,10 +72,18 @@ type CallStack struct {
 // Frames returns the offset slice of called
 // runtime.Frame's in the recorded call stack.
 func (t *CallStack) Frames() []CallFrame {
-	if t.frames == nil {
-		t.fetchCallFrames()
+	if t == nil {
+		return nil
 	}
 
+	// CallStacks which are not created from captured
+	// program counters are initialized with their frames.
+	t.once.Do(func() {
+		if t.frames == nil {
+			t.fetchCallFrames()
+		}
+	})
+
 	if len(t.frames) < t.offset {
 		return nil
 	}
@@ -55,24 +91,66 @@ func (t *CallStack) Frames() []CallFrame {
 	return t.frames[t.offset:]
 }
 
+// Filter returns the frames of the call stack which
+// pass the filters of the given options with rewritten
+// file paths. When no options are given, the options
+// set via SetStackOptions are used.
+func (t *CallStack) Filter(opts ...StackOptions) []CallFrame {
+	return resolveStackOptions(opts).Apply(t.Frames())
+}
+
 // WriteIndent is an alias for write with the given
 // indent string attached before each line of output.
-func (t *CallStack) WriteIndent(w io.Writer, max int, indent string) {
-	frames := t.Frames()
+func (t *CallStack) WriteIndent(w io.Writer, max int, indent string, opts ...StackOptions) {
+	frames := t.Filter(opts...)
 
 	if max > 0 && len(frames) > max {
 		frames = frames[:max]
 	}
 
+	writeFrames(w, frames, indent)
+}
+
+// writeFrames writes the given frames as table with aligned
+// columns of the called function and the file plus line
+// number with the given indent before each line.
+func writeFrames(w io.Writer, frames []CallFrame, indent string) {
 	maxLenFName := 0
 	for _, frame := range frames {
 		if l := len(frame.Function); l > maxLenFName {
 			maxLenFName = l
 		}
 	}
+
+	var b []byte
 	for _, frame := range frames {
-		fmt.Fprintf(w, "%s%"+strconv.Itoa(maxLenFName)+"s\n", indent, frame)
+		b = append(b, indent...)
+		b = frame.appendTo(b, maxLenFName)
+		b = append(b, '\n')
+	}
+
+	w.Write(b)
+}
+
+// sharedFrames returns the number of frames at the end
+// of a which are equal to the frames at the end of b.
+func sharedFrames(a, b []CallFrame) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[len(a)-1-n].equal(b[len(b)-1-n]) {
+		n++
+	}
+	return n
+}
+
+// equal reports whether both frames refer to the same
+// call. The program counters are compared if both frames
+// have one, so that different calls in the same line are
+// distinguished.
+func (t CallFrame) equal(o CallFrame) bool {
+	if t.PC != 0 && o.PC != 0 {
+		return t.PC == o.PC
 	}
+	return t.Function == o.Function && t.File == o.File && t.Line == o.Line
 }
 
 // Write formats the call stack into a table of called
@@ -81,8 +159,13 @@ func (t *CallStack) WriteIndent(w io.Writer, max int, indent string) {
 //
 // max defines the number of stack frames which are
 // printed starting from the original caller.
-func (t *CallStack) Write(w io.Writer, max int) {
-	t.WriteIndent(w, max, "")
+//
+// The frames are filtered and their file paths are
+// rewritten according to the given options. When no
+// options are given, the options set via SetStackOptions
+// are used.
+func (t *CallStack) Write(w io.Writer, max int, opts ...StackOptions) {
+	t.WriteIndent(w, max, "", opts...)
 }
 
 // String returns the formatted output of the callstack
@@ -94,9 +177,10 @@ func (t *CallStack) String() string {
 }
 
 // At returns the formatted call frame at the given position n
+// of the frames filtered by the options set via SetStackOptions
 // if existent.
 func (t *CallStack) At(n int) (s string, ok bool) {
-	frames := t.Frames()
+	frames := t.Filter()
 
 	if n >= len(frames) || n < 0 {
 		return "", false
@@ -113,26 +197,88 @@ func (t *CallStack) First() (s string, ok bool) {
 }
 
 func newCallStack(offset int, n int) *CallStack {
-	callerPtrs := make([]uintptr, n)
-	nPtrs := runtime.Callers(2, callerPtrs)
+	buf := getCallersBuffer(n)
+	nPtrs := runtime.Callers(2, *buf)
 
 	return &CallStack{
-		ptrs:   callerPtrs[:nPtrs],
+		ptrs:   putCallersBuffer(buf, nPtrs),
 		offset: offset,
 	}
 }
 
-func (t *CallStack) fetchCallFrames() {
-	frameCursor := runtime.CallersFrames(t.ptrs)
+// callersPool contains buffers used to capture
+// program counters before they are copied into
+// a slice of the actually used size.
+var callersPool = sync.Pool{
+	New: func() any {
+		buf := make([]uintptr, DefaultCaptureDepth)
+		return &buf
+	},
+}
 
-	callFrames := make([]CallFrame, 0, len(t.ptrs))
+// getCallersBuffer returns a pooled buffer with
+// the length n to be passed to runtime.Callers.
+func getCallersBuffer(n int) *[]uintptr {
+	buf := callersPool.Get().(*[]uintptr)
+	if cap(*buf) < n {
+		*buf = make([]uintptr, n)
+	}
+	*buf = (*buf)[:n]
+	return buf
+}
+
+// putCallersBuffer returns a copy of the first n
+// program counters in buf and puts buf back into
+// the pool.
+func putCallersBuffer(buf *[]uintptr, n int) []uintptr {
+	ptrs := make([]uintptr, n)
+	copy(ptrs, *buf)
+	callersPool.Put(buf)
+	return ptrs
+}
+
+// frameCache maps program counters to their resolved
+// CallFrames. It is shared by all CallStacks, so each
+// call site is only resolved once per process.
+var frameCache = struct {
+	sync.RWMutex
+	frames map[uintptr][]CallFrame
+}{
+	frames: make(map[uintptr][]CallFrame),
+}
+
+// resolveFrames returns the CallFrames of the given
+// program counter as recorded by runtime.Callers.
+func resolveFrames(pc uintptr) []CallFrame {
+	frameCache.RLock()
+	frames, ok := frameCache.frames[pc]
+	frameCache.RUnlock()
+
+	if ok {
+		return frames
+	}
+
+	frameCursor := runtime.CallersFrames([]uintptr{pc})
 	for {
 		frame, more := frameCursor.Next()
-		callFrames = append(callFrames, CallFrame(frame))
+		frames = append(frames, CallFrame(frame))
 		if !more {
 			break
 		}
 	}
 
+	frameCache.Lock()
+	frameCache.frames[pc] = frames
+	frameCache.Unlock()
+
+	return frames
+}
+
+func (t *CallStack) fetchCallFrames() {
+	callFrames := make([]CallFrame, 0, len(t.ptrs))
+	for _, pc := range t.ptrs {
+		callFrames = append(callFrames, resolveFrames(pc)...)
+	}
+
 	t.frames = callFrames
 }
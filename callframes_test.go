@@ -3,6 +3,7 @@ package elk
 import (
 	"fmt"
 	"runtime"
+	"sync/atomic"
 	"testing"
 
 	"github.com/studio-b12/elk/internal/assert"
@@ -27,7 +28,7 @@ func TestFrames(t *testing.T) {
 func Test_stackCaptureGC(t *testing.T) {
 
 	var cs *CallStack
-	var cleanedUp bool
+	var cleanedUp atomic.Bool
 
 	{
 		f := func() {
@@ -35,7 +36,7 @@ func Test_stackCaptureGC(t *testing.T) {
 		}
 
 		runtime.SetFinalizer(&f, func(a any) {
-			cleanedUp = true
+			cleanedUp.Store(true)
 		})
 
 		f()
@@ -48,8 +49,44 @@ func Test_stackCaptureGC(t *testing.T) {
 	runtime.GC()
 	runtime.GC()
 
-	assert.True(t, cleanedUp)
+	assert.True(t, cleanedUp.Load())
 	for _, frame := range cs.Frames() {
 		fmt.Printf("frame: %s\n", frame)
 	}
 }
+
+func TestFrameCache(t *testing.T) {
+	capture := func() *CallStack {
+		return newCallStack(0, 32)
+	}
+
+	var a, b *CallStack
+	for i := 0; i < 2; i++ {
+		a, b = b, capture()
+	}
+	assert.Equal(t, len(a.ptrs), cap(a.ptrs))
+
+	framesA, framesB := a.Frames(), b.Frames()
+	assert.Equal(t, len(framesA), len(framesB))
+	for i := range framesA {
+		assert.Equal(t, framesA[i].PC, framesB[i].PC)
+	}
+
+	// The resolved frames must equal the frames
+	// resolved from the whole stack at once.
+	cursor := runtime.CallersFrames(a.ptrs)
+	for i := 0; ; i++ {
+		frame, more := cursor.Next()
+		assert.Equal(t, frame.Function, framesA[i].Function)
+		assert.Equal(t, frame.Line, framesA[i].Line)
+		if !more {
+			assert.Equal(t, i+1, len(framesA))
+			break
+		}
+	}
+
+	frameCache.RLock()
+	_, ok := frameCache.frames[a.ptrs[0]]
+	frameCache.RUnlock()
+	assert.True(t, ok)
+}
@@ -0,0 +1,136 @@
+package elk
+
+import (
+	"math"
+	"runtime"
+	"sync/atomic"
+)
+
+// CaptureMode defines whether and how the CallStack
+// of an Error is captured on creation.
+type CaptureMode int
+
+const (
+	// CaptureDefault uses the mode of the global
+	// CapturePolicy. As global mode, it is equal
+	// to CaptureFull.
+	CaptureDefault CaptureMode = iota
+
+	// CaptureFull captures the full call stack up
+	// to the configured depth.
+	CaptureFull
+
+	// CaptureCaller only captures the caller which
+	// created the Error.
+	CaptureCaller
+
+	// CaptureDisabled does not capture any call
+	// stack, so the CallStack of the Error is nil.
+	CaptureDisabled
+)
+
+func (t CaptureMode) String() string {
+	switch t {
+	case CaptureFull:
+		return "full"
+	case CaptureCaller:
+		return "caller"
+	case CaptureDisabled:
+		return "disabled"
+	default:
+		return "default"
+	}
+}
+
+// CapturePolicy defines how call stacks are captured
+// when Errors are created.
+type CapturePolicy struct {
+	// Mode defines whether and how the call
+	// stack is captured.
+	Mode CaptureMode
+
+	// Depth is the maximum number of frames which
+	// are captured in CaptureFull mode. When 0, the
+	// depth of the global policy is used, which
+	// defaults to DefaultCaptureDepth.
+	Depth int
+}
+
+// noCapture can be passed as skip to the internal
+// constructors to create an Error without capturing
+// a call stack, for example when the Error is only
+// used to build a response model. It stays negative
+// when the constructors add their own frames.
+const noCapture = math.MinInt32
+
+// DefaultCaptureDepth is the maximum number of frames
+// captured when no depth is configured.
+const DefaultCaptureDepth = 100
+
+var defaultCapturePolicy atomic.Pointer[CapturePolicy]
+
+func init() {
+	defaultCapturePolicy.Store(&CapturePolicy{
+		Mode:  CaptureFull,
+		Depth: DefaultCaptureDepth,
+	})
+}
+
+// SetCapturePolicy sets the global CapturePolicy applied
+// to all created Errors, unless the code of the Error is
+// registered in the DefaultRegistry with a Capture policy
+// whose mode is not CaptureDefault.
+func SetCapturePolicy(policy CapturePolicy) {
+	defaultCapturePolicy.Store(&policy)
+}
+
+// capturePolicy returns the effective CapturePolicy
+// for Errors with the given code.
+func capturePolicy(code ErrorCode) CapturePolicy {
+	global := *defaultCapturePolicy.Load()
+	if global.Depth <= 0 {
+		global.Depth = DefaultCaptureDepth
+	}
+
+	policy := global
+	if !DefaultRegistry.hasCapture.Load() {
+		return policy
+	}
+
+	if info, ok := DefaultRegistry.Lookup(code); ok && info.Capture.Mode != CaptureDefault {
+		policy = info.Capture
+		if policy.Depth <= 0 {
+			policy.Depth = global.Depth
+		}
+	}
+
+	return policy
+}
+
+// captureCallStack captures the call stack for an Error
+// with the given code according to its CapturePolicy.
+// The first captured frame is the caller of the function
+// calling captureCallStack when skip is 0. Each increment
+// of skip omits one further caller.
+func captureCallStack(skip int, code ErrorCode) *CallStack {
+	if skip < 0 {
+		return nil
+	}
+
+	policy := capturePolicy(code)
+
+	n := policy.Depth
+	switch policy.Mode {
+	case CaptureDisabled:
+		return nil
+	case CaptureCaller:
+		n = 1
+	}
+
+	buf := getCallersBuffer(n)
+	nPtrs := runtime.Callers(skip+3, *buf)
+
+	return &CallStack{
+		ptrs: putCallersBuffer(buf, nPtrs),
+	}
+}
@@ -0,0 +1,112 @@
+package elk
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/studio-b12/elk/internal/assert"
+)
+
+var errCaptureDisabled = MustRegister("capture-disabled", CodeInfo{
+	Capture: CapturePolicy{Mode: CaptureDisabled},
+})
+
+func TestCaptureOrigin(t *testing.T) {
+	const ErrCode = ErrorCode("some-error-code")
+
+	plainErr := errors.New("some error")
+	problem, _ := ProblemJson(NewError(ErrCode), 400)
+	model := MustJson(NewError(ErrCode), 400)
+
+	cases := map[string]func() Error{
+		"NewError":            func() Error { return NewError(ErrCode) },
+		"NewErrorf":           func() Error { return NewErrorf(ErrCode, "%d", 1) },
+		"NewErrorWithDetails": func() Error { return NewErrorWithDetails(ErrCode, 1) },
+		"Wrap":                func() Error { return Wrap(ErrCode, plainErr) },
+		"Wrapf":               func() Error { return Wrapf(ErrCode, plainErr, "%d", 1) },
+		"WrapWithDetails":     func() Error { return WrapWithDetails(ErrCode, plainErr, 1) },
+		"WrapCopyCode":        func() Error { return WrapCopyCode(plainErr) },
+		"WrapCopyCodef":       func() Error { return WrapCopyCodef(plainErr, "%d", 1) },
+		"Cast":                func() Error { return Cast(plainErr) },
+		"Cast-join":           func() Error { return Cast(errors.Join(plainErr, plainErr)) },
+		"Cast-multi":          func() Error { return Cast(NewMultiError(plainErr)) },
+		"FromResponseModel":   func() Error { return FromResponseModel(ErrorResponseModel{Code: ErrCode}) },
+		"DecodeJson":          func() Error { e, _ := DecodeJson(model); return e },
+		"DecodeProblem":       func() Error { e, _ := DecodeProblem(problem); return e },
+		"Decode":              func() Error { e, _ := DefaultProblemEncoder.Decode(problem); return e },
+		"Validation": func() Error {
+			v := NewValidation()
+			v.Add("f", ErrCode, "")
+			return Cast(v.Err())
+		},
+		"MultiError.Add": func() Error { return NewMultiError(plainErr).Errors()[0] },
+	}
+
+	for name, newErr := range cases {
+		t.Run(name, func(t *testing.T) {
+			frame, ok := newErr().CallStack().First()
+			assert.True(t, ok)
+			assert.True(t, strings.HasPrefix(frame, "github.com/studio-b12/elk.TestCaptureOrigin.func"))
+		})
+	}
+}
+
+func TestCapturePolicy(t *testing.T) {
+	const ErrCode = ErrorCode("some-error-code")
+
+	defer SetCapturePolicy(*defaultCapturePolicy.Load())
+
+	t.Run("disabled", func(t *testing.T) {
+		SetCapturePolicy(CapturePolicy{Mode: CaptureDisabled})
+
+		err := NewError(ErrCode)
+		assert.Nil(t, err.CallStack())
+		assert.Equal(t, 0, len(err.CallStack().Frames()))
+
+		_, ok := err.CallStack().First()
+		assert.False(t, ok)
+		assert.Equal(t, "", err.CallStack().String())
+		assert.False(t, strings.Contains(fmt.Sprintf("%+v", err), "stack:"))
+	})
+
+	t.Run("caller", func(t *testing.T) {
+		SetCapturePolicy(CapturePolicy{Mode: CaptureCaller})
+
+		err := NewError(ErrCode)
+		frames := err.CallStack().Frames()
+		assert.Equal(t, 1, len(frames))
+		assert.Equal(t, "github.com/studio-b12/elk.TestCapturePolicy.func2", frames[0].Function)
+	})
+
+	t.Run("full", func(t *testing.T) {
+		SetCapturePolicy(CapturePolicy{Mode: CaptureFull, Depth: 2})
+
+		err := Wrap(ErrCode, errors.New("some error"))
+		frames := err.CallStack().Frames()
+		assert.Equal(t, 2, len(frames))
+		assert.Equal(t, "github.com/studio-b12/elk.TestCapturePolicy.func3", frames[0].Function)
+	})
+
+	t.Run("per-code", func(t *testing.T) {
+		SetCapturePolicy(CapturePolicy{Mode: CaptureFull})
+
+		assert.Nil(t, NewError(errCaptureDisabled).CallStack())
+		assert.True(t, len(NewError(ErrCode).CallStack().Frames()) > 2)
+
+		// The stack of the outer error is shown when the
+		// inner error has no call stack.
+		err := Wrap(ErrCode, NewError(errCaptureDisabled))
+		assert.True(t, strings.Contains(fmt.Sprintf("%+v", err), "TestCapturePolicy.func4"))
+	})
+
+	t.Run("recover-caller", func(t *testing.T) {
+		SetCapturePolicy(CapturePolicy{Mode: CaptureCaller})
+
+		err := recoverPanic(func() { panicWithValue("oh no") })
+		frames := Cast(err).CallStack().Frames()
+		assert.Equal(t, 1, len(frames))
+		assert.Equal(t, "github.com/studio-b12/elk.panicWithValue", frames[0].Function)
+	})
+}
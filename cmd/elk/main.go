@@ -0,0 +1,116 @@
+// Command elk provides tooling for errors created
+// with the elk package.
+//
+// Usage:
+//
+//	elk symbolize [flags] <binary> [raw call stack ...]
+//
+// The symbolize command resolves raw call stacks, as
+// produced by CallStack.Raw().MarshalText(), using the
+// given ELF binary which captured them. If no raw call
+// stacks are passed as arguments, they are read line by
+// line from stdin. The frames are printed in the same
+// table layout as produced by CallStack.WriteIndent.
+//
+// Flags:
+//
+//	-max int
+//		maximum number of printed frames per call stack (0 prints all)
+//	-indent string
+//		string printed before each frame
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/studio-b12/elk"
+)
+
+const usage = `Usage:
+  elk symbolize [flags] <binary> [raw call stack ...]
+
+Commands:
+  symbolize   resolve raw call stacks using the binary which captured them
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+
+	switch os.Args[1] {
+	case "symbolize":
+		err = runSymbolize(os.Args[2:], os.Stdin, os.Stdout, os.Stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(os.Stdout, usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func runSymbolize(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("symbolize", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	max := flags.Int("max", 0, "maximum number of printed frames per call stack (0 prints all)")
+	indent := flags.String("indent", "", "string printed before each frame")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() < 1 {
+		return fmt.Errorf("no binary given")
+	}
+
+	s, err := openSymbolizer(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	stacks := flags.Args()[1:]
+	if len(stacks) == 0 {
+		scanner := bufio.NewScanner(stdin)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				stacks = append(stacks, line)
+			}
+		}
+		if err = scanner.Err(); err != nil {
+			return err
+		}
+	}
+
+	for i, text := range stacks {
+		var raw elk.RawCallStack
+		if err = raw.UnmarshalText([]byte(text)); err != nil {
+			return fmt.Errorf("call stack %d: %w", i, err)
+		}
+
+		if err = s.checkBuild(raw); err != nil {
+			fmt.Fprintf(stderr, "warning: call stack %d: %s\n", i, err)
+		}
+
+		if i > 0 {
+			fmt.Fprintln(stdout)
+		}
+
+		s.symbolize(raw).WriteIndent(stdout, *max, *indent, elk.StackOptions{})
+	}
+
+	return nil
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"debug/buildinfo"
+	"debug/dwarf"
+	"debug/elf"
+	"debug/gosym"
+	"errors"
+	"fmt"
+
+	"github.com/studio-b12/elk"
+	"github.com/studio-b12/elk/internal/buildid"
+)
+
+// symbolizer resolves program counters of a Go ELF
+// binary into call frames.
+type symbolizer struct {
+	table  *gosym.Table
+	dwarf  *dwarf.Data
+	anchor uintptr
+	build  elk.RawCallStack
+	cache  map[uintptr][]elk.CallFrame
+}
+
+// openSymbolizer reads the symbol and line tables of
+// the ELF binary at the given path. DWARF data is used,
+// if present, to resolve inlined functions.
+func openSymbolizer(path string) (*symbolizer, error) {
+	f, err := elf.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	pclntab := f.Section(".gopclntab")
+	text := f.Section(".text")
+	if pclntab == nil || text == nil {
+		return nil, errors.New("binary does not contain a Go line table")
+	}
+
+	pclnData, err := pclntab.Data()
+	if err != nil {
+		return nil, err
+	}
+
+	table, err := gosym.NewTable(nil, gosym.NewLineTable(pclnData, text.Addr))
+	if err != nil {
+		return nil, err
+	}
+
+	s := symbolizer{
+		table: table,
+		cache: make(map[uintptr][]elk.CallFrame),
+	}
+
+	// DWARF data is not present in binaries built
+	// with -ldflags=-w. Inlined frames are reported
+	// as the function they have been inlined into then.
+	s.dwarf, _ = f.DWARF()
+
+	if fn := table.LookupFunc(elk.AnchorFunction); fn != nil {
+		s.anchor = uintptr(fn.Entry)
+	}
+
+	s.build.BuildID = buildid.Read(f)
+
+	if info, err := buildinfo.ReadFile(path); err == nil {
+		s.build.Module = info.Main.Path
+		s.build.Version = info.Main.Version
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				s.build.Revision = setting.Value
+			}
+		}
+	}
+
+	return &s, nil
+}
+
+// checkBuild returns an error if the build information
+// of the raw call stack does not match the binary or if
+// it is not sufficient to identify the binary.
+func (t *symbolizer) checkBuild(raw elk.RawCallStack) error {
+	if raw.BuildID != "" && t.build.BuildID != "" {
+		if raw.BuildID != t.build.BuildID {
+			return fmt.Errorf("call stack has been captured by build ID %s, but binary has build ID %s",
+				raw.BuildID, t.build.BuildID)
+		}
+		return nil
+	}
+
+	if raw.Module != t.build.Module ||
+		raw.Version != t.build.Version ||
+		raw.Revision != t.build.Revision {
+		return fmt.Errorf("call stack has been captured by %s, but binary is %s",
+			buildString(raw), buildString(t.build))
+	}
+
+	if raw.Revision == "" && (raw.Version == "" || raw.Version == "(devel)") {
+		return errors.New("call stack contains neither a build ID nor a version or " +
+			"revision, so it can not be verified that it has been captured by the binary")
+	}
+
+	return nil
+}
+
+// symbolize resolves the frames of the given
+// raw call stack.
+func (t *symbolizer) symbolize(raw elk.RawCallStack) *elk.CallStack {
+	return raw.Symbolize(t.anchor, t.resolve)
+}
+
+// resolve returns the frame of the given program
+// counter as recorded by runtime.Callers.
+func (t *symbolizer) resolve(pc uintptr) []elk.CallFrame {
+	if frames, ok := t.cache[pc]; ok {
+		return frames
+	}
+
+	frame := elk.CallFrame{PC: pc}
+
+	file, line, fn := t.table.PCToLine(uint64(pc - 1))
+	if fn != nil {
+		// Program counters are return addresses, so the
+		// call instruction precedes them.
+		if uint64(pc) > fn.Entry {
+			frame.PC = pc - 1
+		}
+
+		frame.Function = fn.Name
+		frame.File = file
+		frame.Line = line
+		frame.Entry = uintptr(fn.Entry)
+
+		if name, ok := t.inlined(uint64(frame.PC)); ok {
+			frame.Function = name
+		}
+	}
+
+	frames := []elk.CallFrame{frame}
+	t.cache[pc] = frames
+	return frames
+}
+
+// inlined returns the name of the innermost function
+// which has been inlined at the given program counter.
+func (t *symbolizer) inlined(pc uint64) (string, bool) {
+	if t.dwarf == nil {
+		return "", false
+	}
+
+	r := t.dwarf.Reader()
+	if _, err := r.SeekPC(pc); err != nil {
+		return "", false
+	}
+
+	var origin dwarf.Offset
+	found := false
+
+	for depth := 0; depth >= 0; {
+		e, err := r.Next()
+		if err != nil || e == nil {
+			break
+		}
+
+		if e.Tag == 0 {
+			depth--
+			continue
+		}
+
+		contained := false
+		if e.Tag == dwarf.TagSubprogram || e.Tag == dwarf.TagInlinedSubroutine ||
+			e.Tag == dwarf.TagLexDwarfBlock {
+			ranges, _ := t.dwarf.Ranges(e)
+			for _, rg := range ranges {
+				if pc >= rg[0] && pc < rg[1] {
+					contained = true
+					break
+				}
+			}
+		}
+
+		if contained && e.Tag == dwarf.TagInlinedSubroutine {
+			origin, found = e.Val(dwarf.AttrAbstractOrigin).(dwarf.Offset)
+		}
+
+		if e.Children {
+			if contained {
+				depth++
+			} else {
+				r.SkipChildren()
+			}
+		}
+	}
+
+	if !found {
+		return "", false
+	}
+
+	r.Seek(origin)
+	e, err := r.Next()
+	if err != nil || e == nil {
+		return "", false
+	}
+
+	name, ok := e.Val(dwarf.AttrName).(string)
+	return name, ok
+}
+
+func buildString(raw elk.RawCallStack) string {
+	s := raw.Module + "@" + raw.Version
+	if raw.Revision != "" {
+		s += " (" + raw.Revision + ")"
+	}
+	return s
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/studio-b12/elk"
+	"github.com/studio-b12/elk/internal/assert"
+)
+
+//go:noinline
+func newTestError() elk.Error {
+	return wrapTestError(errors.New("some error"))
+}
+
+// Binaries built by go test do not contain DWARF data,
+// so inlined functions can not be resolved.
+//
+//go:noinline
+func wrapTestError(err error) elk.Error {
+	return elk.Wrap("some-error-code", err)
+}
+
+func TestSymbolize(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("symbolization is only supported for ELF binaries")
+	}
+
+	exe, err := os.Executable()
+	assert.Nil(t, err)
+
+	stack := newTestError().CallStack()
+	raw, err := stack.Raw().MarshalText()
+	assert.Nil(t, err)
+
+	var expected bytes.Buffer
+	stack.WriteIndent(&expected, 0, "  ", elk.StackOptions{})
+
+	t.Run("args", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		err = runSymbolize([]string{"-indent", "  ", exe, string(raw)}, nil, &stdout, &stderr)
+		assert.Nil(t, err)
+		assert.Equal(t, "", stderr.String())
+		assert.Equal(t, expected.String(), stdout.String())
+		assert.True(t, strings.Contains(stdout.String(), "cmd/elk.wrapTestError"))
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		stdin := strings.NewReader(string(raw) + "\n\n" + string(raw) + "\n")
+		err = runSymbolize([]string{"-indent", "  ", exe}, stdin, &stdout, &stderr)
+		assert.Nil(t, err)
+		assert.Equal(t, expected.String()+"\n"+expected.String(), stdout.String())
+	})
+
+	t.Run("max", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		err = runSymbolize([]string{"-max", "1", exe, string(raw)}, nil, &stdout, &stderr)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, strings.Count(stdout.String(), "\n"))
+		assert.True(t, strings.HasPrefix(stdout.String(), "github.com/studio-b12/elk/cmd/elk.wrapTestError"))
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		assert.True(t, stack.Raw().BuildID != "")
+
+		check := func(modify func(raw *elk.RawCallStack)) string {
+			other := stack.Raw()
+			modify(&other)
+			otherText, _ := other.MarshalText()
+
+			var stdout, stderr bytes.Buffer
+			err = runSymbolize([]string{exe, string(otherText)}, nil, &stdout, &stderr)
+			assert.Nil(t, err)
+			return stderr.String()
+		}
+
+		warning := check(func(raw *elk.RawCallStack) {
+			raw.BuildID = "other-build-id"
+		})
+		assert.True(t, strings.HasPrefix(warning, "warning: call stack 0: call stack has been captured by build ID other-build-id"))
+
+		warning = check(func(raw *elk.RawCallStack) {
+			raw.BuildID = ""
+			raw.Revision = "other-revision"
+		})
+		assert.True(t, strings.Contains(warning, "other-revision"))
+
+		warning = check(func(raw *elk.RawCallStack) {
+			raw.BuildID = ""
+		})
+		assert.True(t, strings.Contains(warning, "neither a build ID"))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		err = runSymbolize([]string{exe, "invalid"}, nil, &stdout, &stderr)
+		assert.True(t, err != nil)
+	})
+}
+
+func TestSymbolizeBuild(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("symbolization is only supported for ELF binaries")
+	}
+
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command is not available")
+	}
+
+	for _, mode := range []string{"exe", "pie"} {
+		t.Run(mode, func(t *testing.T) {
+			exe := filepath.Join(t.TempDir(), "stackprinter")
+
+			build := exec.Command(goBin, "build", "-buildmode="+mode, "-o", exe, "./testdata/stackprinter")
+			if out, err := build.CombinedOutput(); err != nil {
+				t.Skipf("building test binary failed: %s\n%s", err, out)
+			}
+
+			out, err := exec.Command(exe).Output()
+			assert.Nil(t, err)
+
+			raw, expected, _ := strings.Cut(string(out), "\n")
+
+			var stdout, stderr bytes.Buffer
+			err = runSymbolize([]string{exe, raw}, nil, &stdout, &stderr)
+			assert.Nil(t, err)
+			assert.Equal(t, "", stderr.String())
+			assert.Equal(t, expected, stdout.String())
+			assert.True(t, strings.HasPrefix(stdout.String(), "main.inlined "))
+		})
+	}
+}
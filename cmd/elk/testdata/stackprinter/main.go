@@ -0,0 +1,32 @@
+// Command stackprinter prints the raw call stack of an
+// error followed by its in-process formatted call stack.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/studio-b12/elk"
+)
+
+func inlined(err error) elk.Error {
+	return elk.Wrap("some-error-code", err)
+}
+
+//go:noinline
+func newError() elk.Error {
+	return inlined(errors.New("some error"))
+}
+
+func main() {
+	stack := newError().CallStack()
+
+	raw, err := stack.Raw().MarshalText()
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(string(raw))
+	stack.WriteIndent(os.Stdout, 0, "", elk.StackOptions{})
+}
@@ -0,0 +1,294 @@
+package elk
+
+import (
+	"encoding/json"
+	"reflect"
+	"runtime"
+)
+
+// diagnosticFrame is the serialized form of a CallFrame.
+type diagnosticFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// diagnosticAttr is the serialized form of an Attr.
+type diagnosticAttr struct {
+	Key   string `json:"key"`
+	Value any    `json:"value"`
+}
+
+// diagnosticLayer is the serialized form of a single
+// error in the tree. Parent is the index of the layer
+// of the error wrapping it, or -1 for the root error.
+type diagnosticLayer struct {
+	Parent   int               `json:"parent"`
+	Type     string            `json:"type"`
+	Join     bool              `json:"join,omitempty"`
+	Text     string            `json:"text,omitempty"`
+	Code     ErrorCode         `json:"code,omitempty"`
+	Message  string            `json:"message,omitempty"`
+	Internal string            `json:"internal_message,omitempty"`
+	Details  any               `json:"details,omitempty"`
+	Attrs    []diagnosticAttr  `json:"attrs,omitempty"`
+	Stack    []diagnosticFrame `json:"stack,omitempty"`
+}
+
+type diagnostic struct {
+	Layers    []diagnosticLayer `json:"layers"`
+	Truncated string            `json:"truncated,omitempty"`
+}
+
+// DecodedError represents an error in a chain decoded by
+// UnmarshalDiagnostic which has not been an Error when
+// it was encoded.
+type DecodedError struct {
+	// Type is the type name of the original error.
+	Type string
+	// Text is the result of Error() of the original error.
+	Text string
+
+	inner error
+}
+
+func (t *DecodedError) Error() string {
+	return t.Text
+}
+
+func (t *DecodedError) Unwrap() error {
+	return t.inner
+}
+
+// DecodedJoinError represents an error in a tree decoded
+// by UnmarshalDiagnostic which has wrapped multiple errors
+// via `Unwrap() []error`, like errors created with
+// errors.Join, when it was encoded.
+type DecodedJoinError struct {
+	// Type is the type name of the original error.
+	Type string
+	// Text is the result of Error() of the original error.
+	Text string
+
+	inner []error
+}
+
+func (t *DecodedJoinError) Error() string {
+	return t.Text
+}
+
+func (t *DecodedJoinError) Unwrap() []error {
+	return t.inner
+}
+
+// MarshalDiagnostic encodes the whole tree of the given
+// error into JSON for diagnostic purposes.
+//
+// In contrast to Json, the encoding contains the type name,
+// code, message, details, attributes and the resolved call
+// frames of each error in the tree. Because this exposes
+// internal information of the application, the result must
+// not be passed to clients. Use it to ship errors to log
+// collectors or between internal services instead.
+//
+// Errors which are not of type Error are encoded with their
+// type name and the result of Error(). Errors wrapping
+// multiple errors, like errors created with errors.Join and
+// MultiErrors, are encoded with all of their wrapped errors.
+// When the tree has been truncated because of a cycle or the
+// maximum unwrap depth, the reason is recorded in the
+// encoding.
+func MarshalDiagnostic(err error) ([]byte, error) {
+	var (
+		d diagnostic
+		// parents contains the index of the last
+		// layer visited at each depth.
+		parents []int
+	)
+
+	walkTree(err, func(err error, depth int, _ []int) bool {
+		layer := diagnosticLayer{
+			Parent: -1,
+			Type:   reflect.TypeOf(err).String(),
+		}
+
+		if depth > 0 {
+			layer.Parent = parents[depth-1]
+		}
+		parents = append(parents[:depth], len(d.Layers))
+
+		if e, ok := err.(Error); ok {
+			layer.Code = e.code
+			layer.Message = e.message
+			layer.Internal = e.internal
+
+			if e.details != nil {
+				layer.Details = e.details.value
+			}
+
+			for _, attr := range e.attrs.list() {
+				layer.Attrs = append(layer.Attrs, diagnosticAttr{Key: attr.Key, Value: attr.Value})
+			}
+
+			for _, frame := range e.CallStack().Frames() {
+				layer.Stack = append(layer.Stack, diagnosticFrame{
+					Function: frame.Function,
+					File:     frame.File,
+					Line:     frame.Line,
+				})
+			}
+		} else {
+			layer.Text = err.Error()
+			_, layer.Join = err.(interface{ Unwrap() []error })
+
+			if c, ok := err.(HasCode); ok {
+				layer.Code = c.Code()
+			}
+		}
+
+		d.Layers = append(d.Layers, layer)
+		return true
+	}, func(_ int, _ []int, tr truncation) {
+		if d.Truncated == "" {
+			d.Truncated = tr.String()
+		}
+	})
+
+	return json.Marshal(d)
+}
+
+// UnmarshalDiagnostic reconstructs the tree of errors
+// encoded by MarshalDiagnostic.
+//
+// Errors which have been of type Error are reconstructed
+// as Error with their code, message, details, attributes
+// and a CallStack which returns the decoded frames.
+// MultiErrors are reconstructed as *MultiError with their
+// contained errors and code. Other errors which have wrapped
+// multiple errors are reconstructed as *DecodedJoinError.
+// All remaining errors are reconstructed as *DecodedError.
+//
+// Details and attribute values are decoded into their
+// generic JSON representation.
+func UnmarshalDiagnostic(data []byte) (error, error) {
+	var d diagnostic
+	err := json.Unmarshal(data, &d)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(d.Layers) == 0 {
+		return nil, nil
+	}
+
+	children := make([][]int, len(d.Layers))
+	for i, layer := range d.Layers {
+		if (i == 0) != (layer.Parent == -1) || layer.Parent >= i {
+			return nil, NewError(CodeUnexpected, "data contains an invalid parent index")
+		}
+		if i > 0 {
+			children[layer.Parent] = append(children[layer.Parent], i)
+		}
+	}
+
+	// Wrapped errors are always encoded after the errors
+	// wrapping them, so the layers are decoded in reverse.
+	errs := make([]error, len(d.Layers))
+	for i := len(d.Layers) - 1; i >= 0; i-- {
+		inner := make([]error, 0, len(children[i]))
+		for _, child := range children[i] {
+			inner = append(inner, errs[child])
+		}
+
+		errs[i], err = decodeLayer(d.Layers[i], inner)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return errs[0], nil
+}
+
+func decodeLayer(layer diagnosticLayer, inner []error) (error, error) {
+	if !layer.Join && len(inner) > 1 {
+		return nil, NewError(CodeUnexpected, "data contains a single error wrapping multiple errors")
+	}
+
+	switch layer.Type {
+	case reflect.TypeOf(Error{}).String():
+		return decodeErrorLayer(layer, inner), nil
+
+	case reflect.TypeOf((*MultiError)(nil)).String():
+		if m, ok := decodeMultiErrorLayer(layer, inner); ok {
+			return m, nil
+		}
+	}
+
+	if layer.Join {
+		return &DecodedJoinError{
+			Type:  layer.Type,
+			Text:  layer.Text,
+			inner: inner,
+		}, nil
+	}
+
+	e := &DecodedError{
+		Type: layer.Type,
+		Text: layer.Text,
+	}
+	if len(inner) > 0 {
+		e.inner = inner[0]
+	}
+
+	return e, nil
+}
+
+func decodeErrorLayer(layer diagnosticLayer, inner []error) Error {
+	e := Error{
+		code:     layer.Code,
+		message:  layer.Message,
+		internal: layer.Internal,
+	}
+
+	if len(inner) > 0 {
+		e.Inner = inner[0]
+	}
+
+	if layer.Details != nil {
+		e.details = &detailsBox{value: layer.Details}
+	}
+
+	for _, attr := range layer.Attrs {
+		e = e.With(attr.Key, attr.Value)
+	}
+
+	frames := make([]CallFrame, 0, len(layer.Stack))
+	for _, frame := range layer.Stack {
+		frames = append(frames, CallFrame(runtime.Frame{
+			Function: frame.Function,
+			File:     frame.File,
+			Line:     frame.Line,
+		}))
+	}
+	e.callStack = &CallStack{frames: frames}
+
+	return e
+}
+
+// decodeMultiErrorLayer reconstructs a MultiError, if all
+// inner errors are of type Error. The encoded code is kept
+// as fallback code of the AggregateFallback policy, because
+// the original policy is not encoded.
+func decodeMultiErrorLayer(layer diagnosticLayer, inner []error) (*MultiError, bool) {
+	errs := make([]Error, 0, len(inner))
+	for _, err := range inner {
+		e, ok := err.(Error)
+		if !ok {
+			return nil, false
+		}
+		errs = append(errs, e)
+	}
+
+	m := &MultiError{errs: errs}
+	return m.SetPolicy(AggregateFallback, layer.Code), true
+}
@@ -0,0 +1,117 @@
+package elk
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/studio-b12/elk/internal/assert"
+)
+
+func TestDiagnostic(t *testing.T) {
+	const (
+		ErrCode      = ErrorCode("some-error-code")
+		ErrOtherCode = ErrorCode("some-other-error-code")
+	)
+
+	var err error = Wrap(ErrCode, errors.New("some error"), "some message").
+		With("id", 42)
+	err = fmt.Errorf("intermediate: %w", err)
+	err = Wrap(ErrOtherCode, err).WithDetails("some details")
+
+	data, mErr := MarshalDiagnostic(err)
+	assert.Nil(t, mErr)
+
+	decoded, uErr := UnmarshalDiagnostic(data)
+	assert.Nil(t, uErr)
+
+	assert.Equal(t, fmt.Sprintf("%+v", err), fmt.Sprintf("%+v", decoded))
+	assert.Equal(t, err.Error(), decoded.Error())
+
+	outer, ok := decoded.(Error)
+	assert.True(t, ok)
+	assert.Equal(t, ErrOtherCode, outer.Code())
+	assert.Equal[any](t, "some details", outer.Details())
+	assert.True(t, len(outer.CallStack().Frames()) > 0)
+
+	originalFrame, _ := Cast(err).CallStack().First()
+	decodedFrame, _ := outer.CallStack().First()
+	assert.Equal(t, originalFrame, decodedFrame)
+
+	intermediate, ok := errors.Unwrap(decoded).(*DecodedError)
+	assert.True(t, ok)
+	assert.Equal(t, "*fmt.wrapError", intermediate.Type)
+
+	inner, ok := As[Error](intermediate)
+	assert.True(t, ok)
+	assert.Equal(t, ErrCode, inner.Code())
+	assert.Equal(t, "some message", inner.Message())
+	assert.Equal(t, "[id=42]", fmt.Sprint(inner.Attrs()))
+	assert.True(t, errors.Is(decoded, ErrCode.Sentinel()))
+
+	leaf, ok := UnwrapFull(decoded).(*DecodedError)
+	assert.True(t, ok)
+	assert.Equal(t, "*errors.errorString", leaf.Type)
+	assert.Equal(t, "some error", leaf.Error())
+
+	_, uErr = UnmarshalDiagnostic([]byte("invalid"))
+	assert.True(t, uErr != nil)
+}
+
+func TestDiagnosticTree(t *testing.T) {
+	const (
+		ErrCode  = ErrorCode("some-error-code")
+		ErrCodeA = ErrorCode("some-error-code-a")
+		ErrCodeB = ErrorCode("some-error-code-b")
+	)
+
+	t.Run("join", func(t *testing.T) {
+		err := Wrap(ErrCode, errors.Join(NewError(ErrCodeA, "a"), NewError(ErrCodeB, "b")))
+
+		data, mErr := MarshalDiagnostic(err)
+		assert.Nil(t, mErr)
+
+		decoded, uErr := UnmarshalDiagnostic(data)
+		assert.Nil(t, uErr)
+
+		assert.Equal(t, fmt.Sprint(Codes(err)), fmt.Sprint(Codes(decoded)))
+		assert.Equal(t, "[some-error-code some-error-code-a some-error-code-b]", fmt.Sprint(Codes(decoded)))
+		assert.Equal(t, err.Error(), decoded.Error())
+
+		join, ok := Find[*DecodedJoinError](decoded)
+		assert.True(t, ok)
+		assert.Equal(t, "*errors.joinError", join.Type)
+		assert.Equal(t, 2, len(join.Unwrap()))
+
+		b, ok := join.Unwrap()[1].(Error)
+		assert.True(t, ok)
+		assert.Equal(t, "b", b.Message())
+		assert.True(t, len(b.CallStack().Frames()) > 0)
+	})
+
+	t.Run("multi", func(t *testing.T) {
+		multi := NewMultiError(NewError(ErrCodeA, "a"), NewError(ErrCodeB, "b")).
+			SetPolicy(AggregateFirst, CodeUnexpected)
+		err := Wrap(ErrCode, multi)
+
+		data, mErr := MarshalDiagnostic(err)
+		assert.Nil(t, mErr)
+
+		decoded, uErr := UnmarshalDiagnostic(data)
+		assert.Nil(t, uErr)
+
+		assert.Equal(t, fmt.Sprint(Codes(err)), fmt.Sprint(Codes(decoded)))
+		assert.Equal(t, fmt.Sprintf("%+v", err), fmt.Sprintf("%+v", decoded))
+
+		decodedMulti, ok := Find[*MultiError](decoded)
+		assert.True(t, ok)
+		assert.Equal(t, ErrCodeA, decodedMulti.Code())
+		assert.Equal(t, 2, decodedMulti.Len())
+		assert.Equal(t, ErrCodeB, decodedMulti.Errors()[1].Code())
+	})
+
+	t.Run("invalid-parent", func(t *testing.T) {
+		_, err := UnmarshalDiagnostic([]byte(`{"layers":[{"parent":-1,"type":"x"},{"parent":1,"type":"y"}]}`))
+		assert.True(t, err != nil)
+	})
+}
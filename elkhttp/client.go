@@ -0,0 +1,147 @@
+package elkhttp
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/studio-b12/elk"
+)
+
+// DecodeResponse reads the body of the given response
+// and decodes it into an elk.Error. Both, the JSON
+// representation of the elk.ErrorResponseModel as
+// produced by the elk.DefaultEncoder and RFC 9457
+// problem details documents are supported.
+//
+// If the decoded model has no status code set, the
+// status code of the response is used.
+//
+// The body of the response is consumed but not closed.
+func DecodeResponse(resp *http.Response) (elk.Error, error) {
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return elk.Error{}, err
+	}
+
+	return decodeResponseBody(resp, data, elk.DefaultEncoder)
+}
+
+func decodeResponseBody(resp *http.Response, data []byte, enc *elk.Encoder) (elk.Error, error) {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+
+	if mediaType == elk.ProblemContentType {
+		e, err := elk.DecodeProblem(data)
+		if err != nil {
+			return elk.Error{}, err
+		}
+
+		if p, ok := elk.As[*elk.ProblemDetails](e); ok && p.Status == 0 {
+			p.Status = resp.StatusCode
+		}
+
+		return e, nil
+	}
+
+	e, err := enc.Decode(data)
+	if err != nil {
+		return elk.Error{}, err
+	}
+
+	if model, ok := elk.As[*elk.ErrorResponseModel](e); ok && model.Status == 0 {
+		model.Status = resp.StatusCode
+	}
+
+	return e, nil
+}
+
+// DefaultMaxErrorBodySize is the maximum size in bytes
+// of response bodies which are decoded by a Transport
+// when no MaxBodySize is set.
+const DefaultMaxErrorBodySize = 1 << 20
+
+// Transport is a http.RoundTripper which converts
+// responses with a non-2xx status code carrying an
+// error response body into returned elk.Errors.
+//
+// Only bodies with the media type "application/json"
+// or "application/problem+json" which are not larger
+// than MaxBodySize are decoded. All other responses,
+// as well as responses whose body can not be decoded,
+// are returned unchanged with their complete body.
+type Transport struct {
+	// Base is the wrapped http.RoundTripper. If not
+	// set, http.DefaultTransport is used.
+	Base http.RoundTripper
+
+	// MaxBodySize is the maximum size in bytes of error
+	// response bodies which are read for decoding. If not
+	// set, DefaultMaxErrorBodySize is used.
+	MaxBodySize int64
+
+	// Encoder, if set, is used to decode the JSON
+	// representation of the elk.ErrorResponseModel, so
+	// the Naming and Envelope must match the Encoder of
+	// the server. Otherwise, the elk.DefaultEncoder is
+	// used.
+	Encoder *elk.Encoder
+}
+
+var _ http.RoundTripper = (*Transport)(nil)
+
+// RoundTrip executes the request using the Base
+// RoundTripper and converts error responses into
+// elk.Errors.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "application/json" && mediaType != elk.ProblemContentType {
+		return resp, nil
+	}
+
+	maxSize := t.MaxBodySize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxErrorBodySize
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	if int64(len(data)) <= maxSize {
+		enc := t.Encoder
+		if enc == nil {
+			enc = elk.DefaultEncoder
+		}
+
+		if e, dErr := decodeResponseBody(resp, data, enc); dErr == nil {
+			resp.Body.Close()
+			return nil, e
+		}
+	}
+
+	// The already read part of the body is prepended
+	// to the rest of the body, which is left unread.
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), resp.Body), resp.Body}
+
+	return resp, nil
+}
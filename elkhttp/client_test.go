@@ -0,0 +1,143 @@
+package elkhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/studio-b12/elk"
+	"github.com/studio-b12/elk/internal/assert"
+)
+
+func TestTransport(t *testing.T) {
+	responder := &Responder{
+		StatusCodes: map[elk.ErrorCode]int{errNotFound: http.StatusNotFound},
+	}
+	enveloped := &Responder{
+		StatusCodes: map[elk.ErrorCode]int{errNotFound: http.StatusNotFound},
+		Encoder:     &elk.Encoder{Naming: elk.SnakeCase, Envelope: "error"},
+	}
+	problemResponder := &Responder{
+		StatusCodes: map[elk.ErrorCode]int{errNotFound: http.StatusNotFound},
+		Problem:     &elk.ProblemEncoder{},
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/ok", responder.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		_, _ = io.WriteString(w, "ok")
+		return nil
+	}))
+	mux.Handle("/not-found", responder.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		return elk.NewErrorWithDetails(errNotFound, []int{1, 2}, "not found")
+	}))
+	mux.Handle("/problem", problemResponder.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		return elk.NewError(errNotFound, "not found")
+	}))
+	mux.Handle("/enveloped", enveloped.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		return elk.NewError(errNotFound, "not found")
+	}))
+	mux.HandleFunc("/plain", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "plain error", http.StatusBadGateway)
+	})
+	mux.HandleFunc("/text-code", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, `{"code":"NOT_FOUND"}`)
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, `{"code":"not-found","message":"`+strings.Repeat("a", 100)+`"}`)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := &http.Client{Transport: &Transport{}}
+
+	t.Run("ok", func(t *testing.T) {
+		resp, err := client.Get(server.URL + "/ok")
+		assert.Nil(t, err)
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, "ok", string(body))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := client.Get(server.URL + "/not-found")
+		assert.True(t, elk.IsOfType[*url.Error](err))
+
+		e, ok := elk.As[elk.Error](err)
+		assert.True(t, ok)
+		assert.Equal(t, errNotFound, e.Code())
+		assert.Equal(t, "not found", e.Message())
+		assert.Equal(t, "[1,2]", string(e.Details().(json.RawMessage)))
+
+		model, ok := elk.As[*elk.ErrorResponseModel](err)
+		assert.True(t, ok)
+		assert.Equal(t, http.StatusNotFound, model.Status)
+	})
+
+	t.Run("problem", func(t *testing.T) {
+		_, err := client.Get(server.URL + "/problem")
+		assert.True(t, errors.Is(err, errNotFound.Sentinel()))
+
+		p, ok := elk.As[*elk.ProblemDetails](err)
+		assert.True(t, ok)
+		assert.Equal(t, http.StatusNotFound, p.Status)
+	})
+
+	t.Run("encoder", func(t *testing.T) {
+		resp, err := client.Get(server.URL + "/enveloped")
+		assert.Nil(t, err)
+		resp.Body.Close()
+
+		client := &http.Client{Transport: &Transport{Encoder: enveloped.Encoder}}
+		_, err = client.Get(server.URL + "/enveloped")
+
+		e, ok := elk.As[elk.Error](err)
+		assert.True(t, ok)
+		assert.Equal(t, errNotFound, e.Code())
+		assert.Equal(t, "not found", e.Message())
+	})
+
+	t.Run("plain", func(t *testing.T) {
+		resp, err := client.Get(server.URL + "/plain")
+		assert.Nil(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, "plain error\n", string(body))
+	})
+
+	t.Run("media-type", func(t *testing.T) {
+		resp, err := client.Get(server.URL + "/text-code")
+		assert.Nil(t, err)
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, `{"code":"NOT_FOUND"}`, string(body))
+	})
+
+	t.Run("too-large", func(t *testing.T) {
+		client := &http.Client{Transport: &Transport{MaxBodySize: 64}}
+
+		resp, err := client.Get(server.URL + "/large")
+		assert.Nil(t, err)
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, 133, len(body))
+
+		client = &http.Client{Transport: &Transport{MaxBodySize: 133}}
+		_, err = client.Get(server.URL + "/large")
+		assert.True(t, errors.Is(err, errNotFound.Sentinel()))
+	})
+}
@@ -0,0 +1,3 @@
+// Package elkhttp provides utilities to use elk
+// errors in net/http servers.
+package elkhttp
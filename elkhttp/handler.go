@@ -0,0 +1,183 @@
+package elkhttp
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/studio-b12/elk"
+)
+
+// HandlerFunc is a http handler function which
+// can return an error.
+//
+// HandlerFunc implements http.Handler. Returned
+// errors are written using the DefaultResponder.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+var _ http.Handler = (HandlerFunc)(nil)
+
+// ServeHTTP calls the handler function and writes
+// returned errors using the DefaultResponder.
+func (t HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	DefaultResponder.Handler(t).ServeHTTP(w, r)
+}
+
+// Handler returns a http.Handler which calls the
+// given handler function and writes returned errors
+// using the DefaultResponder.
+func Handler(h HandlerFunc) http.Handler {
+	return DefaultResponder.Handler(h)
+}
+
+// Handler returns a http.Handler which calls the given
+// handler function and writes returned errors using
+// the Responder.
+//
+// Panics in the handler function are recovered via
+// elk.RecoverValue and written as error with the code
+// elk.CodeUnexpected.
+// http.ErrAbortHandler is re-panicked to preserve
+// its semantics.
+//
+// The http.ResponseWriter passed to the handler function
+// implements http.Flusher, http.Hijacker and io.ReaderFrom
+// when the original http.ResponseWriter does.
+func (t *Responder) Handler(h HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sw := &statusWriter{ResponseWriter: w}
+		hw := sw.wrap()
+
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+
+			t.Respond(sw, r, elk.RecoverValue(v))
+		}()
+
+		err := h(hw, r)
+		if err != nil {
+			t.Respond(sw, r, err)
+		}
+	})
+}
+
+// headerWriter is implemented by the response writers
+// passed to handlers to report if the response header
+// has already been written.
+type headerWriter interface {
+	headerWritten() bool
+}
+
+// statusWriter wraps a http.ResponseWriter and records
+// if the response header has already been written.
+type statusWriter struct {
+	http.ResponseWriter
+
+	wroteHeader bool
+}
+
+func (t *statusWriter) WriteHeader(statusCode int) {
+	t.wroteHeader = true
+	t.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (t *statusWriter) Write(p []byte) (int, error) {
+	t.wroteHeader = true
+	return t.ResponseWriter.Write(p)
+}
+
+// Unwrap returns the wrapped http.ResponseWriter so that
+// it can be accessed by http.ResponseController.
+func (t *statusWriter) Unwrap() http.ResponseWriter {
+	return t.ResponseWriter
+}
+
+func (t *statusWriter) headerWritten() bool {
+	return t.wroteHeader
+}
+
+// wrap returns the statusWriter as http.ResponseWriter
+// which implements the optional interfaces http.Flusher,
+// http.Hijacker and io.ReaderFrom exactly when the
+// wrapped http.ResponseWriter implements them, so that
+// type assertions in handlers keep working.
+func (t *statusWriter) wrap() http.ResponseWriter {
+	_, isFlusher := t.ResponseWriter.(http.Flusher)
+	_, isHijacker := t.ResponseWriter.(http.Hijacker)
+	_, isReaderFrom := t.ResponseWriter.(io.ReaderFrom)
+
+	f, h, rf := flusher{t}, hijacker{t}, readerFrom{t}
+
+	switch {
+	case isFlusher && isHijacker && isReaderFrom:
+		return struct {
+			*statusWriter
+			http.Flusher
+			http.Hijacker
+			io.ReaderFrom
+		}{t, f, h, rf}
+	case isFlusher && isHijacker:
+		return struct {
+			*statusWriter
+			http.Flusher
+			http.Hijacker
+		}{t, f, h}
+	case isFlusher && isReaderFrom:
+		return struct {
+			*statusWriter
+			http.Flusher
+			io.ReaderFrom
+		}{t, f, rf}
+	case isHijacker && isReaderFrom:
+		return struct {
+			*statusWriter
+			http.Hijacker
+			io.ReaderFrom
+		}{t, h, rf}
+	case isFlusher:
+		return struct {
+			*statusWriter
+			http.Flusher
+		}{t, f}
+	case isHijacker:
+		return struct {
+			*statusWriter
+			http.Hijacker
+		}{t, h}
+	case isReaderFrom:
+		return struct {
+			*statusWriter
+			io.ReaderFrom
+		}{t, rf}
+	}
+
+	return t
+}
+
+type flusher struct{ *statusWriter }
+
+func (t flusher) Flush() {
+	t.wroteHeader = true
+	t.ResponseWriter.(http.Flusher).Flush()
+}
+
+type hijacker struct{ *statusWriter }
+
+func (t hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	t.wroteHeader = true
+	return t.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+type readerFrom struct{ *statusWriter }
+
+func (t readerFrom) ReadFrom(r io.Reader) (int64, error) {
+	t.wroteHeader = true
+	return t.ResponseWriter.(io.ReaderFrom).ReadFrom(r)
+}
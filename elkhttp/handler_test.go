@@ -0,0 +1,237 @@
+package elkhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/studio-b12/elk"
+	"github.com/studio-b12/elk/internal/assert"
+)
+
+const errNotFound = elk.ErrorCode("not-found")
+
+var errConflict = elk.MustRegister("elkhttp-conflict", elk.CodeInfo{Status: http.StatusConflict})
+
+func serve(h http.Handler) (*httptest.ResponseRecorder, elk.ErrorResponseModel) {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/foo", nil))
+
+	var model elk.ErrorResponseModel
+	_ = json.Unmarshal(rec.Body.Bytes(), &model)
+
+	return rec, model
+}
+
+func TestHandler(t *testing.T) {
+	var logs bytes.Buffer
+	responder := &Responder{
+		StatusCodes: map[elk.ErrorCode]int{errNotFound: http.StatusNotFound},
+		Logger:      slog.New(slog.NewTextHandler(&logs, nil)),
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		rec, _ := serve(responder.Handler(func(w http.ResponseWriter, r *http.Request) error {
+			_, _ = io.WriteString(w, "ok")
+			return nil
+		}))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "ok", rec.Body.String())
+	})
+
+	t.Run("mapped", func(t *testing.T) {
+		logs.Reset()
+		rec, model := serve(responder.Handler(func(w http.ResponseWriter, r *http.Request) error {
+			return elk.NewError(errNotFound, "not found")
+		}))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		assert.Equal(t, errNotFound, model.Code)
+		assert.Equal(t, "not found", model.Message)
+		assert.Equal(t, http.StatusNotFound, model.Status)
+		assert.Equal(t, 0, logs.Len())
+	})
+
+	t.Run("registered", func(t *testing.T) {
+		rec, model := serve(responder.Handler(func(w http.ResponseWriter, r *http.Request) error {
+			return elk.NewError(errConflict)
+		}))
+
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Equal(t, errConflict, model.Code)
+	})
+
+	t.Run("wrapped", func(t *testing.T) {
+		logs.Reset()
+		rec, model := serve(responder.Handler(func(w http.ResponseWriter, r *http.Request) error {
+			return fmt.Errorf("get: %w", elk.NewError(errNotFound, "nf"))
+		}))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, errNotFound, model.Code)
+		assert.Equal(t, "nf", model.Message)
+		assert.Equal(t, http.StatusNotFound, responder.Status(fmt.Errorf("get: %w", elk.NewError(errNotFound))))
+		assert.Equal(t, 0, logs.Len())
+	})
+
+	t.Run("unexpected", func(t *testing.T) {
+		logs.Reset()
+		rec, model := serve(responder.Handler(func(w http.ResponseWriter, r *http.Request) error {
+			return errors.New("some error")
+		}))
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Equal(t, elk.CodeUnexpected, model.Code)
+		assert.True(t, strings.Contains(logs.String(), `err="some error"`))
+		assert.False(t, strings.Contains(logs.String(), "err.origin="))
+		assert.False(t, strings.Contains(logs.String(), "err.stack="))
+	})
+
+	t.Run("unexpected-wrapped", func(t *testing.T) {
+		logs.Reset()
+		rec, _ := serve(responder.Handler(func(w http.ResponseWriter, r *http.Request) error {
+			return fmt.Errorf("loading: %w", elk.NewError("elkhttp-unmapped", "unmapped"))
+		}))
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.True(t, strings.Contains(logs.String(), `err.text="loading: `))
+		assert.True(t, strings.Contains(logs.String(), `err.origin="github.com/studio-b12/elk/elkhttp.TestHandler.func`))
+		assert.True(t, strings.Contains(logs.String(), "err.code=elkhttp-unmapped"))
+		assert.False(t, strings.Contains(logs.String(), "responder.go"))
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		logs.Reset()
+		rec, model := serve(responder.Handler(func(w http.ResponseWriter, r *http.Request) error {
+			panic("oh no")
+		}))
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Equal(t, elk.CodeUnexpected, model.Code)
+		assert.True(t, strings.Contains(logs.String(), "oh no"))
+		assert.True(t, strings.Contains(logs.String(), "elkhttp.TestHandler.func"))
+	})
+
+	t.Run("after-write", func(t *testing.T) {
+		logs.Reset()
+		rec, _ := serve(responder.Handler(func(w http.ResponseWriter, r *http.Request) error {
+			w.WriteHeader(http.StatusAccepted)
+			return elk.NewError(errNotFound)
+		}))
+
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+		assert.Equal(t, 0, rec.Body.Len())
+		assert.True(t, strings.Contains(logs.String(), "after writing response"))
+	})
+}
+
+func TestHandlerDebug(t *testing.T) {
+	responder := &Responder{
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Encoder: &elk.Encoder{Debug: true},
+	}
+
+	_, model := serve(responder.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		return errors.New("some error")
+	}))
+
+	assert.Equal(t, "some error", model.Debug.Chain[0])
+}
+
+func TestHandlerProblem(t *testing.T) {
+	responder := &Responder{
+		StatusCodes: map[elk.ErrorCode]int{errNotFound: http.StatusNotFound},
+		Problem:     &elk.ProblemEncoder{BaseURI: "https://example.com/problems/"},
+	}
+
+	rec, _ := serve(responder.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		return elk.NewError(errNotFound, "not found")
+	}))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, elk.ProblemContentType, rec.Header().Get("Content-Type"))
+
+	err, dErr := elk.DecodeProblem(rec.Body.Bytes())
+	assert.Nil(t, dErr)
+	assert.Equal(t, errNotFound, err.Code())
+	assert.Equal(t, "not found", err.Message())
+}
+
+func TestHandlerFunc(t *testing.T) {
+	logger := DefaultResponder.Logger
+	DefaultResponder.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	defer func() { DefaultResponder.Logger = logger }()
+
+	rec, model := serve(HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return elk.NewError(errNotFound)
+	}))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, errNotFound, model.Code)
+}
+
+type plainWriter struct {
+	http.ResponseWriter
+}
+
+func TestHandlerOptionalInterfaces(t *testing.T) {
+	var (
+		isFlusher    bool
+		isHijacker   bool
+		isReaderFrom bool
+	)
+
+	h := (&Responder{}).Handler(func(w http.ResponseWriter, r *http.Request) error {
+		_, isFlusher = w.(http.Flusher)
+		_, isHijacker = w.(http.Hijacker)
+		_, isReaderFrom = w.(io.ReaderFrom)
+
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		return nil
+	})
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/foo", nil))
+	assert.True(t, isFlusher)
+	assert.False(t, isHijacker)
+	assert.False(t, isReaderFrom)
+	assert.True(t, rec.Flushed)
+
+	h.ServeHTTP(plainWriter{httptest.NewRecorder()}, httptest.NewRequest("GET", "/foo", nil))
+	assert.False(t, isFlusher)
+
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL)
+	assert.Nil(t, err)
+	res.Body.Close()
+	assert.True(t, isFlusher)
+	assert.True(t, isHijacker)
+	assert.True(t, isReaderFrom)
+}
+
+func TestHandlerErrorAfterFlush(t *testing.T) {
+	var logs bytes.Buffer
+	responder := &Responder{Logger: slog.New(slog.NewTextHandler(&logs, nil))}
+
+	rec, _ := serve(responder.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		w.(http.Flusher).Flush()
+		return elk.NewError(errNotFound)
+	}))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 0, rec.Body.Len())
+	assert.True(t, strings.Contains(logs.String(), "after writing response"))
+}
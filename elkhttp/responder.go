@@ -0,0 +1,194 @@
+package elkhttp
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/studio-b12/elk"
+)
+
+// Responder writes errors as HTTP responses
+// containing the JSON representation of the
+// elk.ErrorResponseModel.
+//
+// The zero value is ready to use.
+type Responder struct {
+	// StatusCodes maps ErrorCodes to HTTP status
+	// codes. Codes which are not present are looked
+	// up in the elk.DefaultRegistry.
+	StatusCodes map[elk.ErrorCode]int
+
+	// DefaultStatus is the HTTP status code used
+	// for errors with codes which are neither present
+	// in StatusCodes nor registered. Defaults to
+	// http.StatusInternalServerError if not set.
+	DefaultStatus int
+
+	// Logger is used to log unexpected errors,
+	// which are errors responded with a status
+	// code of 500 or higher. Defaults to
+	// slog.Default() if not set.
+	Logger *slog.Logger
+
+	// StackDepth is the maximum number of call
+	// frames logged with unexpected errors.
+	// If it is 0, all frames are logged.
+	StackDepth int
+
+	// Encoder, if set, is used to encode the
+	// elk.ErrorResponseModel. Otherwise, the
+	// elk.DefaultEncoder is used.
+	Encoder *elk.Encoder
+
+	// Problem, if set, is used to write errors as
+	// RFC 9457 problem details documents instead
+	// of the elk.ErrorResponseModel.
+	Problem *elk.ProblemEncoder
+}
+
+// DefaultResponder is the Responder used by
+// HandlerFunc and Handler.
+var DefaultResponder = &Responder{StackDepth: 10}
+
+// Status returns the HTTP status code for the
+// given error.
+//
+// The status code is taken from StatusCodes. If the
+// code of the error is not present, the status code
+// registered in the elk.DefaultRegistry is used. If
+// none is registered, DefaultStatus is returned.
+//
+// If err is not an elk.Error, the code of the first
+// elk.Error in the tree of err with a status code
+// is used, so errors wrapped via fmt.Errorf keep
+// their status.
+func (t *Responder) Status(err error) int {
+	_, status := t.resolve(err)
+	return status
+}
+
+// resolve returns the error to respond with and its
+// status code. It is the first elk.Error in the tree
+// of err with a status code, if err is not an
+// elk.Error itself. Otherwise, err is returned.
+func (t *Responder) resolve(err error) (error, int) {
+	if elkErr, ok := err.(elk.Error); ok {
+		return err, t.status(elkErr.Code())
+	}
+
+	var (
+		found  elk.Error
+		status int
+	)
+	elk.Walk(err, func(err error, _ int, _ []int) bool {
+		elkErr, ok := err.(elk.Error)
+		if !ok {
+			return true
+		}
+		found = elkErr
+		status = t.lookup(elkErr.Code())
+		return status == 0
+	})
+
+	if status != 0 {
+		return found, status
+	}
+
+	return err, t.status(elk.Cast(err).Code())
+}
+
+func (t *Responder) status(code elk.ErrorCode) int {
+	if status := t.lookup(code); status != 0 {
+		return status
+	}
+
+	if t.DefaultStatus != 0 {
+		return t.DefaultStatus
+	}
+
+	return http.StatusInternalServerError
+}
+
+// lookup returns the status code present in StatusCodes
+// or registered for the given code, or 0 if there is none.
+func (t *Responder) lookup(code elk.ErrorCode) int {
+	if status, ok := t.StatusCodes[code]; ok {
+		return status
+	}
+
+	if info, ok := elk.Lookup(code); ok {
+		return info.Status
+	}
+
+	return 0
+}
+
+// Respond writes the given error as response to w.
+//
+// The status code is determined as described for
+// Status. If err wraps an elk.Error with a status
+// code, that error is written as response.
+//
+// Unexpected errors are logged with their call stack
+// before the response is written. If the response
+// header has already been written by the handler,
+// the error is only logged.
+func (t *Responder) Respond(w http.ResponseWriter, r *http.Request, err error) {
+	resErr, status := t.resolve(err)
+
+	if hw, ok := w.(headerWriter); ok && hw.headerWritten() {
+		t.log(r, err, status, "handler returned error after writing response")
+		return
+	}
+
+	if status >= http.StatusInternalServerError {
+		t.log(r, err, status, "handler returned unexpected error")
+	}
+
+	contentType := "application/json"
+	encode := elk.DefaultEncoder.Json
+	if t.Encoder != nil {
+		encode = t.Encoder.Json
+	}
+	if t.Problem != nil {
+		contentType = elk.ProblemContentType
+		encode = t.Problem.Json
+	}
+
+	data, jErr := encode(resErr, status)
+	if jErr != nil {
+		t.log(r, jErr, status, "failed encoding error response")
+		w.WriteHeader(status)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
+func (t *Responder) log(r *http.Request, err error, status int, msg string) {
+	logger := t.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	logger.ErrorContext(r.Context(), msg,
+		"method", r.Method,
+		"path", r.URL.Path,
+		"status", status,
+		"err", t.logValue(err))
+}
+
+// logValue returns the log representation of the given
+// error. The origin and call stack are only logged for
+// errors containing an elk.Error, because casting other
+// errors would record the call stack of the responder.
+func (t *Responder) logValue(err error) slog.Value {
+	opts := elk.LogOptions{
+		Fields:     elk.LogDefault | elk.LogStack,
+		StackDepth: t.StackDepth,
+	}
+
+	return opts.ErrorValue(err)
+}
@@ -0,0 +1,619 @@
+package elk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"unicode"
+)
+
+// DebugEnvKey is the name of the environment variable
+// which enables the debug mode globally when set to a
+// true value (see strconv.ParseBool).
+const DebugEnvKey = "ELK_DEBUG"
+
+var debugMode atomic.Bool
+
+func init() {
+	enabled, _ := strconv.ParseBool(os.Getenv(DebugEnvKey))
+	debugMode.Store(enabled)
+}
+
+// SetDebug enables or disables the debug mode globally.
+//
+// In debug mode, the JSON representation of errors
+// contains internal information like the text of all
+// errors in the chain and the call stack. Never enable
+// it in production environments.
+func SetDebug(enabled bool) {
+	debugMode.Store(enabled)
+}
+
+// IsDebug returns whether the debug mode is enabled
+// globally.
+func IsDebug() bool {
+	return debugMode.Load()
+}
+
+// Encoder encodes errors into the JSON representation
+// of the ErrorResponseModel.
+//
+// The zero value is ready to use and produces the same
+// output as Json.
+type Encoder struct {
+	// Debug enables the debug mode for this encoder.
+	// The debug mode is also enabled when enabled
+	// globally via SetDebug or the ELK_DEBUG
+	// environment variable.
+	Debug bool
+
+	// StackDepth is the maximum number of call frames
+	// added in debug mode. Defaults to 10 if not set.
+	// Set to a negative value to add no call frames.
+	StackDepth int
+
+	// Naming transforms the field names of the
+	// ErrorResponseModel into the keys of the JSON
+	// object, i.e. CamelCase or SnakeCase. If not
+	// set, the field names are used as they are.
+	// Keys of the details are only transformed for
+	// detail types of this package, like the
+	// ValidationEntries of validation errors.
+	Naming func(name string) string
+
+	// Compact disables the indentation of the
+	// output.
+	Compact bool
+
+	// Indent is the string used to indent the
+	// output. Defaults to two spaces if not set.
+	Indent string
+
+	// Envelope, if set, is the key of a top-level
+	// object which contains the encoded error, i.e.
+	// `{"error": {...}}`.
+	Envelope string
+
+	// Extra contains static fields which are added
+	// to the top-level object, i.e. an API version.
+	// They are added after the error fields or after
+	// the envelope key, if set. Keys which collide with
+	// a field of the ErrorResponseModel or the envelope
+	// key are skipped, so the error fields always win.
+	Extra map[string]any
+}
+
+// DefaultEncoder is the Encoder used by Json.
+var DefaultEncoder = &Encoder{}
+
+// ResponseModel transforms the error into an
+// ErrorResponseModel. In debug mode, the Debug
+// field of the model is set.
+func (t *Encoder) ResponseModel(err error, statusCode int) ErrorResponseModel {
+	model := cast(noCapture, err, CodeUnexpected).ToResponseModel(statusCode)
+	return t.withDebug(model, err)
+}
+
+// LocalizedResponseModel behaves like ResponseModel but
+// localizes the message using the DefaultLocalizer in the
+// first matching language of langs.
+func (t *Encoder) LocalizedResponseModel(err error, statusCode int, langs ...string) ErrorResponseModel {
+	model := cast(noCapture, err, CodeUnexpected).ToLocalizedResponseModel(statusCode, langs...)
+	return t.withDebug(model, err)
+}
+
+func (t *Encoder) withDebug(model ErrorResponseModel, err error) ErrorResponseModel {
+	if t.Debug || IsDebug() {
+		model.Debug = t.debugInfo(err)
+	}
+
+	return model
+}
+
+// Json transforms the error into an ErrorResponseModel
+// and marshals it into a JSON byte slice.
+func (t *Encoder) Json(err error, statusCode int) ([]byte, error) {
+	return t.marshal(t.ResponseModel(err, statusCode))
+}
+
+// JsonLocalized behaves like Json but localizes the
+// message using the DefaultLocalizer in the first
+// matching language of langs.
+func (t *Encoder) JsonLocalized(err error, statusCode int, langs ...string) ([]byte, error) {
+	return t.marshal(t.LocalizedResponseModel(err, statusCode, langs...))
+}
+
+func (t *Encoder) marshal(model ErrorResponseModel) ([]byte, error) {
+	var b bytes.Buffer
+
+	err := t.encode(&b, model)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(b.Bytes(), []byte{'\n'}), nil
+}
+
+// Encode transforms the error into an ErrorResponseModel
+// and writes its JSON representation followed by a
+// newline character into w.
+//
+// The members are written one after another without
+// buffering the whole document. If encoding a member
+// fails, the members written before are not reverted.
+func (t *Encoder) Encode(w io.Writer, err error, statusCode int) error {
+	return t.encode(w, t.ResponseModel(err, statusCode))
+}
+
+func (t *Encoder) encode(w io.Writer, model ErrorResponseModel) error {
+	var indent string
+	if !t.Compact {
+		indent = t.indent()
+	}
+
+	s := newJsonStream(w, indent)
+	s.value(t.document(model), 0)
+	s.write("\n")
+
+	return s.err
+}
+
+func (t *Encoder) indent() string {
+	if t.Indent == "" {
+		return "  "
+	}
+	return t.Indent
+}
+
+// document builds the top-level JSON object from the
+// given model.
+func (t *Encoder) document(model ErrorResponseModel) jsonObject {
+	obj := t.object(reflect.ValueOf(model))
+
+	if t.Envelope != "" {
+		obj = jsonObject{{key: t.Envelope, value: obj}}
+	}
+
+	if len(t.Extra) > 0 {
+		reserved := []string{t.Envelope}
+		if t.Envelope == "" {
+			reserved = t.keys(reflect.TypeOf(model))
+		}
+
+		keys := make([]string, 0, len(t.Extra))
+		for k := range t.Extra {
+			if !slices.Contains(reserved, k) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			obj = append(obj, jsonMember{key: k, value: t.Extra[k]})
+		}
+	}
+
+	return obj
+}
+
+// object transforms the given struct value into a
+// jsonObject respecting the json tags of its fields
+// and the Naming of the Encoder. Fields of struct
+// types declared in this package are transformed
+// recursively.
+func (t *Encoder) object(v reflect.Value) jsonObject {
+	typ := v.Type()
+	obj := make(jsonObject, 0, typ.NumField())
+
+	for i := 0; i < typ.NumField(); i++ {
+		name, opts, ok := t.key(typ.Field(i))
+		if !ok {
+			continue
+		}
+
+		fv := v.Field(i)
+		if isEmptyValue(fv) && slices.Contains(strings.Split(opts, ","), "omitempty") {
+			continue
+		}
+
+		obj = append(obj, jsonMember{key: name, value: t.value(fv)})
+	}
+
+	return obj
+}
+
+// keys returns the keys of all members of the jsonObject
+// of the given struct type, including the ones which may
+// be omitted.
+func (t *Encoder) keys(typ reflect.Type) []string {
+	keys := make([]string, 0, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		if name, _, ok := t.key(typ.Field(i)); ok {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
+
+// key returns the key and the json tag options of the
+// given struct field, or false if it is not encoded.
+func (t *Encoder) key(field reflect.StructField) (name, opts string, ok bool) {
+	if !field.IsExported() {
+		return "", "", false
+	}
+
+	name, opts, _ = strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return "", "", false
+	}
+	if name == "" {
+		name = field.Name
+		if t.Naming != nil {
+			name = t.Naming(name)
+		}
+	}
+
+	return name, opts, true
+}
+
+func (t *Encoder) value(v reflect.Value) any {
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		return t.value(v.Elem())
+	}
+
+	typ := v.Type()
+
+	switch {
+	case isModelStruct(typ):
+		return t.object(v)
+
+	case typ.Kind() == reflect.Pointer && isModelStruct(typ.Elem()):
+		if v.IsNil() {
+			return nil
+		}
+		return t.object(v.Elem())
+
+	case typ.Kind() == reflect.Slice && isModelStruct(typ.Elem()):
+		if v.IsNil() {
+			return nil
+		}
+		objs := make([]jsonObject, v.Len())
+		for i := range objs {
+			objs[i] = t.object(v.Index(i))
+		}
+		return objs
+	}
+
+	return v.Interface()
+}
+
+// Decode unmarshals the JSON representation of an
+// ErrorResponseModel as produced by the Encoder and
+// transforms it into an Error, respecting the Naming
+// and Envelope of the Encoder. See DecodeJson for
+// more information.
+func (t *Encoder) Decode(data []byte) (Error, error) {
+	return t.decode(1, data)
+}
+
+// decode implements Decode, skipping the given number of
+// callers when capturing the call stack.
+func (t *Encoder) decode(skip int, data []byte) (Error, error) {
+	if t.Envelope != "" {
+		var envelope map[string]json.RawMessage
+		err := json.Unmarshal(data, &envelope)
+		if err != nil {
+			return Error{}, err
+		}
+
+		var ok bool
+		data, ok = envelope[t.Envelope]
+		if !ok {
+			return Error{}, NewError(CodeUnexpected, "data does not contain the envelope key")
+		}
+	}
+
+	if t.Naming != nil {
+		var err error
+		data, err = t.denormalize(data, reflect.TypeOf(ErrorResponseModel{}))
+		if err != nil {
+			return Error{}, err
+		}
+	}
+
+	return decodeJson(skip+1, data)
+}
+
+// denormalize transforms the keys of the given JSON object,
+// encoded from a value of the given struct type, back into
+// the keys expected by encoding/json.
+func (t *Encoder) denormalize(data []byte, typ reflect.Type) ([]byte, error) {
+	if string(bytes.TrimSpace(data)) == "null" {
+		return data, nil
+	}
+
+	var obj map[string]json.RawMessage
+	err := json.Unmarshal(data, &obj)
+	if err != nil {
+		return nil, err
+	}
+
+	plain := Encoder{}
+	res := make(map[string]json.RawMessage, len(obj))
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+
+		name, _, ok := t.key(field)
+		if !ok {
+			continue
+		}
+
+		value, ok := obj[name]
+		if !ok {
+			continue
+		}
+
+		ft := field.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+
+		switch {
+		case isModelStruct(ft):
+			value, err = t.denormalize(value, ft)
+
+		case ft.Kind() == reflect.Slice && isModelStruct(ft.Elem()):
+			var items []json.RawMessage
+			err = json.Unmarshal(value, &items)
+			for i := 0; err == nil && i < len(items); i++ {
+				items[i], err = t.denormalize(items[i], ft.Elem())
+			}
+			if err == nil && items != nil {
+				value, err = json.Marshal(items)
+			}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		name, _, _ = plain.key(field)
+		res[name] = value
+	}
+
+	return json.Marshal(res)
+}
+
+// isModelStruct reports whether typ is a struct type declared
+// in this package which is encoded member by member.
+func isModelStruct(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Struct && typ.PkgPath() == modelPkgPath &&
+		!typ.Implements(marshalerType) && !reflect.PointerTo(typ).Implements(marshalerType)
+}
+
+var marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// isEmptyValue reports whether v is considered empty
+// by the omitempty option of encoding/json.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return v.IsZero()
+}
+
+var modelPkgPath = reflect.TypeOf(ErrorResponseModel{}).PkgPath()
+
+// jsonMember is a key/value pair of a jsonObject.
+type jsonMember struct {
+	key   string
+	value any
+}
+
+// jsonObject is a JSON object which keeps the order of
+// its members when written by a jsonStream.
+type jsonObject []jsonMember
+
+// jsonStream writes jsonObjects and the values of their
+// members directly into a writer. Values other than
+// jsonObjects are encoded by a single json.Encoder.
+type jsonStream struct {
+	w      io.Writer
+	enc    *json.Encoder
+	indent string
+	err    error
+}
+
+func newJsonStream(w io.Writer, indent string) *jsonStream {
+	return &jsonStream{
+		w:      w,
+		enc:    json.NewEncoder(valueWriter{w: w}),
+		indent: indent,
+	}
+}
+
+func (t *jsonStream) write(s string) {
+	if t.err == nil {
+		_, t.err = io.WriteString(t.w, s)
+	}
+}
+
+// newline starts a new line indented to the given depth,
+// if the stream is indented.
+func (t *jsonStream) newline(depth int) {
+	if t.indent != "" {
+		t.write("\n" + strings.Repeat(t.indent, depth))
+	}
+}
+
+func (t *jsonStream) value(v any, depth int) {
+	switch v := v.(type) {
+	case jsonObject:
+		t.write("{")
+		for i, member := range v {
+			if i > 0 {
+				t.write(",")
+			}
+			t.newline(depth + 1)
+			t.value(member.key, depth+1)
+			t.write(":")
+			if t.indent != "" {
+				t.write(" ")
+			}
+			t.value(member.value, depth+1)
+		}
+		if len(v) > 0 {
+			t.newline(depth)
+		}
+		t.write("}")
+
+	case []jsonObject:
+		t.write("[")
+		for i, obj := range v {
+			if i > 0 {
+				t.write(",")
+			}
+			t.newline(depth + 1)
+			t.value(obj, depth+1)
+		}
+		if len(v) > 0 {
+			t.newline(depth)
+		}
+		t.write("]")
+
+	default:
+		if t.err != nil {
+			return
+		}
+		if t.indent != "" {
+			t.enc.SetIndent(strings.Repeat(t.indent, depth), t.indent)
+		}
+		t.err = t.enc.Encode(v)
+	}
+}
+
+// valueWriter writes the values encoded by a json.Encoder
+// without the newline character appended to each of them.
+type valueWriter struct {
+	w io.Writer
+}
+
+func (t valueWriter) Write(p []byte) (int, error) {
+	_, err := t.w.Write(bytes.TrimSuffix(p, []byte{'\n'}))
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// CamelCase transforms the given field name into
+// camelCase, i.e. "StatusCode" into "statusCode"
+// and "HTTPStatus" into "httpStatus". It can be
+// used as Naming of an Encoder.
+func CamelCase(name string) string {
+	words := splitWords(name)
+	for i, word := range words {
+		word = strings.ToLower(word)
+		if i > 0 {
+			word = strings.ToUpper(word[:1]) + word[1:]
+		}
+		words[i] = word
+	}
+	return strings.Join(words, "")
+}
+
+// SnakeCase transforms the given field name into
+// snake_case, i.e. "StatusCode" into "status_code"
+// and "HTTPStatus" into "http_status". It can be
+// used as Naming of an Encoder.
+func SnakeCase(name string) string {
+	words := splitWords(name)
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+	return strings.Join(words, "_")
+}
+
+// splitWords splits the given PascalCase name into
+// its words. Sequences of upper case letters are
+// treated as a single word.
+func splitWords(name string) []string {
+	var words []string
+
+	runes := []rune(name)
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		prev, curr := runes[i-1], runes[i]
+		nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+
+		if unicode.IsUpper(curr) && (unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+			(unicode.IsUpper(prev) && nextIsLower)) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+
+	return words
+}
+
+func (t *Encoder) debugInfo(err error) *DebugInfo {
+	var (
+		info DebugInfo
+		cs   *CallStack
+	)
+
+	tr := unwrapChain(err, func(e error) bool {
+		if ecs, ok := e.(HasCallStack); ok && ecs.CallStack() != nil {
+			cs = ecs.CallStack()
+		}
+
+		if elkErr, ok := e.(Error); ok {
+			info.Chain = append(info.Chain, elkErr.title())
+		} else {
+			info.Chain = append(info.Chain, e.Error())
+		}
+		return true
+	})
+
+	if tr.truncated() {
+		info.Chain = append(info.Chain, "... "+tr.String())
+	}
+
+	depth := t.StackDepth
+	if depth == 0 {
+		depth = 10
+	}
+
+	var frames []CallFrame
+	if cs != nil && depth > 0 {
+		frames = cs.Filter()
+		if len(frames) > depth {
+			frames = frames[:depth]
+		}
+	}
+
+	for _, frame := range frames {
+		info.Stack = append(info.Stack, fmt.Sprintf("%s", frame))
+	}
+
+	return &info
+}
@@ -0,0 +1,208 @@
+package elk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/studio-b12/elk/internal/assert"
+)
+
+func TestEncoderDebug(t *testing.T) {
+	const ErrCode = ErrorCode("some-error-code")
+
+	var err error = Wrap(ErrCode, errors.New("some error"), "some message").
+		WithInternalMessage("some internal message")
+	err = fmt.Errorf("wrapped: %w", err)
+
+	t.Run("default", func(t *testing.T) {
+		data, jErr := Json(err, 500)
+		assert.Nil(t, jErr)
+		assert.False(t, strings.Contains(string(data), "Debug"))
+		assert.False(t, strings.Contains(string(data), "some error"))
+	})
+
+	t.Run("encoder", func(t *testing.T) {
+		enc := Encoder{Debug: true, StackDepth: 2}
+		model := enc.ResponseModel(err, 500)
+
+		assert.Equal(t, CodeUnexpected, model.Code)
+		assert.Equal(t, "", model.Message)
+		assert.Equal(t, 3, len(model.Debug.Chain))
+		assert.Equal(t, err.Error(), model.Debug.Chain[0])
+		assert.Equal(t, "<some-error-code> some message [some internal message]", model.Debug.Chain[1])
+		assert.Equal(t, "some error", model.Debug.Chain[2])
+		assert.Equal(t, 2, len(model.Debug.Stack))
+		assert.True(t, strings.HasPrefix(model.Debug.Stack[0], "github.com/studio-b12/elk.TestEncoderDebug "))
+
+		enc.StackDepth = -1
+		model = enc.ResponseModel(err, 500)
+		assert.Equal(t, 0, len(model.Debug.Stack))
+	})
+
+	t.Run("global", func(t *testing.T) {
+		SetDebug(true)
+		defer SetDebug(false)
+
+		data, jErr := Json(errors.New("some error"), 500)
+		assert.Nil(t, jErr)
+
+		var model ErrorResponseModel
+		_ = json.Unmarshal(data, &model)
+		assert.Equal(t, "some error", model.Debug.Chain[0])
+		assert.Equal(t, 0, len(model.Debug.Stack))
+	})
+}
+
+type writesRecorder struct {
+	writes []string
+}
+
+func (t *writesRecorder) Write(p []byte) (int, error) {
+	t.writes = append(t.writes, string(p))
+	return len(p), nil
+}
+
+func TestNaming(t *testing.T) {
+	cases := []struct {
+		name, camel, snake string
+	}{
+		{"Code", "code", "code"},
+		{"StatusCode", "statusCode", "status_code"},
+		{"HTTPStatus", "httpStatus", "http_status"},
+		{"RequestID", "requestId", "request_id"},
+		{"Base64Data", "base64Data", "base64_data"},
+		{"", "", ""},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.camel, CamelCase(c.name))
+		assert.Equal(t, c.snake, SnakeCase(c.name))
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	const ErrCode = ErrorCode("some-error-code")
+
+	t.Run("default", func(t *testing.T) {
+		err := NewErrorWithDetails(ErrCode, []int{1}, "some message")
+
+		data, jErr := (&Encoder{}).Json(err, 400)
+		assert.Nil(t, jErr)
+
+		expected, _ := json.MarshalIndent(err.ToResponseModel(400), "", "  ")
+		assert.Equal(t, string(expected), string(data))
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		err := NewMultiError(NewError(ErrCode, "some message"), NewError(ErrCode))
+		enc := Encoder{Naming: SnakeCase, Compact: true, Debug: true, StackDepth: -1}
+
+		var buf strings.Builder
+		jErr := enc.Encode(&buf, err, 400)
+		assert.Nil(t, jErr)
+
+		var m map[string]any
+		_ = json.Unmarshal([]byte(buf.String()), &m)
+		assert.Equal[any](t, string(ErrCode), m["code"])
+		assert.Equal[any](t, "some message", m["errors"].([]any)[0].(map[string]any)["message"])
+		assert.Equal[any](t, nil, m["debug"].(map[string]any)["stack"])
+		assert.True(t, strings.HasSuffix(buf.String(), "}\n"))
+		assert.False(t, strings.Contains(buf.String(), "  "))
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		err := NewMultiError(NewErrorWithDetails(ErrCode, map[string]any{"a": []int{1, 2}}, "some message"), NewError(ErrCode))
+
+		var w writesRecorder
+		jErr := (&Encoder{}).Encode(&w, err, 400)
+		assert.Nil(t, jErr)
+		assert.True(t, len(w.writes) > 1)
+
+		expected, _ := json.MarshalIndent(Cast(err).ToResponseModel(400), "", "  ")
+		assert.Equal(t, string(expected)+"\n", strings.Join(w.writes, ""))
+
+		data, _ := (&Encoder{Compact: true}).Json(err, 400)
+		expected, _ = json.Marshal(Cast(err).ToResponseModel(400))
+		assert.Equal(t, string(expected), string(data))
+	})
+
+	t.Run("stream-error", func(t *testing.T) {
+		jErr := (&Encoder{}).Encode(io.Discard, NewErrorWithDetails(ErrCode, func() {}), 400)
+		assert.True(t, jErr != nil)
+
+		_, jErr = (&Encoder{}).Json(NewErrorWithDetails(ErrCode, func() {}), 400)
+		assert.True(t, jErr != nil)
+	})
+
+	t.Run("validation-naming", func(t *testing.T) {
+		v := NewValidation()
+		v.Add("name", ErrCode, "some message")
+
+		data, _ := (&Encoder{Compact: true, Naming: SnakeCase}).Json(v.Err(), 400)
+		assert.True(t, strings.Contains(string(data),
+			`"details":[{"field":"name","code":"some-error-code","message":"some message"}]`))
+	})
+
+	t.Run("decode", func(t *testing.T) {
+		enc := Encoder{
+			Naming:   func(name string) string { return "err_" + SnakeCase(name) },
+			Envelope: "error",
+			Extra:    map[string]any{"version": "v2"},
+		}
+
+		err := NewErrorWithDetails(ErrCode, map[string]any{"Limit": 10}, "some message")
+		data, jErr := enc.Json(err, 400)
+		assert.Nil(t, jErr)
+
+		_, dErr := DecodeJson(data)
+		assert.True(t, dErr != nil)
+
+		decoded, dErr := enc.Decode(data)
+		assert.Nil(t, dErr)
+		assert.Equal(t, ErrCode, decoded.Code())
+		assert.Equal(t, "some message", decoded.Message())
+		assert.Equal(t, `{"Limit":10}`, string(decoded.Details().(json.RawMessage)))
+
+		frame, _ := decoded.CallStack().First()
+		assert.True(t, strings.HasPrefix(frame, "github.com/studio-b12/elk.TestEncoder.func"))
+
+		model, ok := As[*ErrorResponseModel](decoded)
+		assert.True(t, ok)
+		assert.Equal(t, 400, model.Status)
+
+		data, _ = enc.Json(NewMultiError(err), 400)
+		decoded, dErr = enc.Decode(data)
+		assert.Nil(t, dErr)
+
+		model, _ = As[*ErrorResponseModel](decoded)
+		assert.Equal(t, 1, len(model.Errors))
+		assert.Equal(t, ErrCode, model.Errors[0].Code)
+
+		_, dErr = enc.Decode([]byte(`{"err_code":"some-error-code"}`))
+		assert.True(t, dErr != nil)
+	})
+
+	t.Run("indent", func(t *testing.T) {
+		data, _ := (&Encoder{Indent: "\t"}).Json(NewError(ErrCode), 0)
+		assert.Equal(t, "{\n\t\"Code\": \"some-error-code\"\n}", string(data))
+	})
+
+	t.Run("extra", func(t *testing.T) {
+		extra := map[string]any{"Code": "v2", "Message": "v2", "version": "v2"}
+
+		data, _ := (&Encoder{Compact: true, Extra: extra}).Json(NewError(ErrCode), 400)
+		assert.Equal(t, `{"Code":"some-error-code","Status":400,"version":"v2"}`, string(data))
+
+		data, _ = (&Encoder{Compact: true, Naming: CamelCase, Extra: map[string]any{"code": "v2"}}).
+			Json(NewError(ErrCode), 0)
+		assert.Equal(t, `{"code":"some-error-code"}`, string(data))
+
+		data, _ = (&Encoder{Compact: true, Envelope: "error", Extra: map[string]any{"error": 1, "Code": "v2"}}).
+			Json(NewError(ErrCode), 0)
+		assert.Equal(t, `{"error":{"Code":"some-error-code"},"Code":"v2"}`, string(data))
+	})
+}
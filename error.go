@@ -1,6 +1,7 @@
 package elk
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
@@ -8,18 +9,36 @@ import (
 	"strings"
 )
 
+// ErrorCode classifies an Error.
 type ErrorCode string
 
-const (
-	CodeUnexpected = ErrorCode("unexpected-error")
-)
+// Sentinel returns an error with the code which can be
+// used as target for `errors.Is` to check if an error
+// chain contains an Error with the code.
+func (t ErrorCode) Sentinel() error {
+	return codeSentinel(t)
+}
+
+// codeSentinel is an error which only consists of an
+// ErrorCode.
+type codeSentinel ErrorCode
+
+func (t codeSentinel) Error() string {
+	return string(t)
+}
+
+func (t codeSentinel) Code() ErrorCode {
+	return ErrorCode(t)
+}
 
 const (
-	maxCallStackDepth = 100
+	CodeUnexpected        = ErrorCode("unexpected-error")
+	CodeAlreadyRegistered = ErrorCode("code-already-registered")
 )
 
 // Error contains a wrapped inner error,
-// an optional message, optional details objects
+// an optional public and internal message,
+// optional details objects
 // and a CallStack from where the error has been
 // created.
 type Error struct {
@@ -27,27 +46,43 @@ type Error struct {
 
 	code      ErrorCode
 	message   string
+	internal  string
+	details   *detailsBox
+	attrs     *attrNode
 	callStack *CallStack
 }
 
+// detailsBox holds the details value of an Error. It is
+// referenced via pointer so that Error stays comparable
+// regardless of the dynamic type of the details value.
+type detailsBox struct {
+	value any
+}
+
 var (
-	_ HasMessage   = (*Error)(nil)
-	_ HasCode      = (*Error)(nil)
-	_ HasCallStack = (*Error)(nil)
+	_ HasMessage         = (*Error)(nil)
+	_ HasInternalMessage = (*Error)(nil)
+	_ HasCode            = (*Error)(nil)
+	_ HasDetails         = (*Error)(nil)
+	_ HasCallStack       = (*Error)(nil)
 )
 
 // NewError creates a new Error with the given code and optional message.
 func NewError(code ErrorCode, message ...string) Error {
-	d := Wrap(code, errors.New(string(code)), message...)
-	d.callStack.offset++
-	return d
+	return newError(1, code, message)
 }
 
 // NewErrorf creates a new Error with the given code and message formatted
 // according to the given format specification.
 func NewErrorf(code ErrorCode, format string, a ...any) Error {
-	e := NewError(code, fmt.Sprintf(format, a...))
-	e.callStack.offset++
+	return newError(1, code, []string{fmt.Sprintf(format, a...)})
+}
+
+// NewErrorWithDetails creates a new Error with the given code, details
+// and optional message.
+func NewErrorWithDetails(code ErrorCode, details any, message ...string) Error {
+	e := newError(1, code, message)
+	e.details = &detailsBox{value: details}
 	return e
 }
 
@@ -65,31 +100,47 @@ func NewErrorf(code ErrorCode, format string, a ...any) Error {
 //   - If it contains more than one elk Error, a new wrapped Error is
 //     returned as well with the passed fallback ErrorCode or CodeUnexpected.
 //
-// If err is of type Error, it is simply returned unchanged.
+// If err is a *MultiError, it is wrapped using the aggregated
+// code of the MultiError.
+//
+// If err is of type Error, it is simply returned unchanged. If err implements
+// HasCode, its code and the public message of the first error in the chain
+// of err which has one are used for the new Error. The message lookup stops
+// at errors with a code registered as internal.
 func Cast(err error, fallback ...ErrorCode) Error {
 	code := CodeUnexpected
 	if len(fallback) > 0 {
 		code = fallback[0]
 	}
 
+	return cast(1, err, code)
+}
+
+// cast implements Cast, skipping the given number of
+// callers when capturing the call stack.
+func cast(skip int, err error, code ErrorCode) Error {
+	if mErr, ok := err.(*MultiError); ok {
+		return wrap(skip+1, mErr.Code(), mErr, nil)
+	}
+
 	if errJoin, ok := err.(interface{ Unwrap() []error }); ok {
 		errs := errJoin.Unwrap()
 		if len(errs) == 0 {
-			return Wrap(code, err)
+			return wrap(skip+1, code, err, nil)
 		}
 
 		var lastElkErr *Error
 		for _, innerErr := range errs {
-			if elkErr, ok := As[Error](innerErr); ok {
+			if elkErr, ok := Find[Error](innerErr); ok {
 				if lastElkErr != nil {
-					return Wrap(code, innerErr)
+					return wrap(skip+1, code, innerErr, nil)
 				}
 				lastElkErr = &elkErr
 			}
 		}
 
 		if lastElkErr == nil {
-			return Wrap(code, err)
+			return wrap(skip+1, code, err, nil)
 		}
 
 		return *lastElkErr
@@ -100,17 +151,15 @@ func Cast(err error, fallback ...ErrorCode) Error {
 		return eErr
 	}
 
-	if c, ok := err.(HasCode); ok {
-		if m, ok := err.(HasMessage); ok {
-			eErr = Wrap(c.Code(), err, m.Message())
-		} else {
-			eErr = Wrap(c.Code(), err)
-		}
-	} else {
-		eErr = Wrap(code, err)
+	c, ok := err.(HasCode)
+	if !ok {
+		return wrap(skip+1, code, err, nil)
 	}
 
-	eErr.callStack.offset++
+	// The message is only taken over together with the
+	// code, so that it never describes a different code.
+	eErr = wrap(skip+1, c.Code(), err, nil)
+	eErr.message = publicMessage(err)
 
 	return eErr
 }
@@ -118,29 +167,63 @@ func Cast(err error, fallback ...ErrorCode) Error {
 // Wrap takes an ErrorCode, error and an optional message and creates a
 // new wrapped Error containing the passed error.
 func Wrap(code ErrorCode, err error, message ...string) Error {
-	var d Error
-
-	d.code = code
-	d.Inner = err
-	d.callStack = newCallStack(1, maxCallStackDepth)
-	d.setMessage(message)
-
-	return d
+	return wrap(1, code, err, message)
 }
 
 // Wrapf takes an ErrorCode, error and a message formatted according to the
 // given format specification and creates a new wrapped Error containing the
 // passed error.
 func Wrapf(code ErrorCode, err error, format string, a ...any) Error {
-	e := Wrap(code, err, fmt.Sprintf(format, a...))
-	e.callStack.offset++
+	return wrap(1, code, err, []string{fmt.Sprintf(format, a...)})
+}
+
+// WrapWithDetails takes an ErrorCode, error, details and an optional message
+// and creates a new wrapped Error containing the passed error.
+func WrapWithDetails(code ErrorCode, err error, details any, message ...string) Error {
+	e := wrap(1, code, err, message)
+	e.details = &detailsBox{value: details}
 	return e
 }
 
 // WrapCopyCode wraps the error with an optional message keeping the error code
 // of the wrapped error. If the wrapped error does not have a error code,
-// CodeUnexpected is set insetad.
+// CodeUnexpected is set insetad. If no message is passed and the code is kept,
+// the public message of the first error in the chain of err which has one is
+// used.
 func WrapCopyCode(err error, message ...string) Error {
+	return wrapCopyCode(1, err, message)
+}
+
+// WrapCopyCodef wraps the error with a message formatted according to the given
+// format specification keeping the error code of the wrapped error. If the
+// wrapped error does not have a error code, CodeUnexpected is set instead.
+func WrapCopyCodef(err error, format string, a ...any) Error {
+	return wrapCopyCode(1, err, []string{fmt.Sprintf(format, a...)})
+}
+
+// newError creates a new Error with the given code and
+// message, skipping the given number of callers when
+// capturing the call stack.
+func newError(skip int, code ErrorCode, message []string) Error {
+	return wrap(skip+1, code, errors.New(string(code)), message)
+}
+
+// wrap creates a new Error wrapping err, skipping the
+// given number of callers when capturing the call stack.
+func wrap(skip int, code ErrorCode, err error, message []string) Error {
+	var d Error
+
+	d.code = code
+	d.Inner = err
+	d.callStack = captureCallStack(skip, code)
+	d.setMessage(message)
+
+	return d
+}
+
+// wrapCopyCode implements WrapCopyCode, skipping the given
+// number of callers when capturing the call stack.
+func wrapCopyCode(skip int, err error, message []string) Error {
 	e, ok := err.(Error)
 
 	code := CodeUnexpected
@@ -148,18 +231,12 @@ func WrapCopyCode(err error, message ...string) Error {
 		code = e.code
 	}
 
-	e = Wrap(code, err, message...)
-	e.callStack.offset++
+	e = wrap(skip+1, code, err, message)
 
-	return e
-}
+	if ok && e.message == "" {
+		e.message = publicMessage(err)
+	}
 
-// WrapCopyCodef wraps the error with a message formatted according to the given
-// format specification keeping the error code of the wrapped error. If the
-// wrapped error does not have a error code, CodeUnexpected is set instead.
-func WrapCopyCodef(err error, format string, a ...any) Error {
-	e := WrapCopyCode(err, fmt.Sprintf(format, a...))
-	e.callStack.offset++
 	return e
 }
 
@@ -174,31 +251,48 @@ func (t Error) Error() string {
 //
 // %s, %q
 //
-// Prints the message of the error, if available. Otherwise, the
+// Prints the public message of the error, if available. Otherwise, the
 // %s format of the inner error is represented. If the inner error is nil
 // and no message is set, the error code is printed.
 //
 // %v
 //
 // Prints a more detailed representation of the error. Without any flags,
-// the error is printed in the format `<{errorCode}> {message} ({innerError})`.
+// the error is printed in the format
+// `<{errorCode}> {message} [{internalMessage}] ({innerError})`.
 //
 // By passing the `+` flag, the inner error is represented in a seperate line.
 // Also, by using the precision parameter, you can specify the depth of the
 // represented callstack (i.E. `%+.5v` - prints a callstack of depth 5). Otherwise,
-// no callstack will be printed.
+// no callstack will be printed. If details or attributes are attached to the
+// error, they are printed as well.
 //
 // Bypassing the `#` flag, an even more verbose representation of the error is
 // printed. It shows the complete chain of errors wrapped in the Error
-// with information about message, code, initiation origin and type of the error.
+// with information about message, code, initiation origin, details, attributes
+// and type of the error. Errors wrapping multiple errors, like joined errors,
+// are represented as tree where each branch is indented and prefixed with
+// its index.
 // With the precision parameter, you can define the depth of the unwrapping. The
-// default value is 100, if not specified.
+// default value is 1000, if not specified.
+//
+// By combining both flags (`%+#v`), the whole chain is rendered similar to
+// Java's "caused by" stack traces instead of the `+` representation. Each layer
+// shows the call stack frames which are not shared with the call stack of the
+// layer below it, followed by the number of omitted frames. The precision
+// parameter limits the number of frames shown per layer (i.E. `%+#.5v`).
+//
+// All formats with flags stop unwrapping at cycles in the chain of errors and
+// at the maximum depth set via SetMaxUnwrapDepth and mark the truncation in
+// the output.
 func (t Error) Format(s fmt.State, verb rune) {
 	precision, hasPrecision := s.Precision()
 
 	switch verb {
 	case 'v':
-		if s.Flag('+') {
+		if s.Flag('+') && s.Flag('#') {
+			t.writeCausedBy(s, precision)
+		} else if s.Flag('+') {
 			if !hasPrecision {
 				precision = 1000
 			}
@@ -219,21 +313,124 @@ func (t Error) Format(s fmt.State, verb rune) {
 	}
 }
 
-// Message returns the errors message text,
-// if specified.
+// Is reports whether the target error has the same
+// ErrorCode as the error. This allows checking for
+// codes of all Errors in a chain using `errors.Is`.
+//
+//	if errors.Is(err, ErrDeviceNotFound.Sentinel()) {
+//		// ...
+//	}
+//
+// Note that, in contrast to the functions of this package,
+// errors.Is itself does not guard against cycles in the
+// chain of errors. Use ContainsCode for chains which
+// may contain errors with faulty Unwrap methods.
+func (t Error) Is(target error) bool {
+	c, ok := target.(HasCode)
+	return ok && c.Code() == t.code
+}
+
+// Message returns the errors public message
+// text, if specified.
 func (t Error) Message() string {
 	return t.message
 }
 
+// InternalMessage returns the errors internal
+// message text, if specified.
+func (t Error) InternalMessage() string {
+	return t.internal
+}
+
+// WithMessage returns a copy of the error with
+// the given public message. The original error
+// is left unchanged.
+//
+// The public message is shown by the %s format
+// and passed into the ErrorResponseModel.
+func (t Error) WithMessage(message string) Error {
+	t.message = message
+	return t
+}
+
+// WithInternalMessage returns a copy of the error
+// with the given internal message. The original
+// error is left unchanged.
+//
+// In contrast to the public message, the internal
+// message is only shown by the %v format and never
+// passed into the ErrorResponseModel.
+func (t Error) WithInternalMessage(message string) Error {
+	t.internal = message
+	return t
+}
+
+// WithInternalMessagef is like WithInternalMessage
+// with the message formatted according to the given
+// format specification.
+func (t Error) WithInternalMessagef(format string, a ...any) Error {
+	return t.WithInternalMessage(fmt.Sprintf(format, a...))
+}
+
 // Code returns the inner ErrorCode of
 // the error.
 func (t Error) Code() ErrorCode {
 	return t.code
 }
 
+// Details returns the details attached to the error.
+//
+// If no details have been attached to this error, the
+// details of the first error in the chain of wrapped
+// errors implementing HasDetails are returned instead.
+// If there is none, nil is returned.
+func (t Error) Details() any {
+	if t.details != nil {
+		return t.details.value
+	}
+
+	return findDetails(t.Inner, Walk)
+}
+
+// findDetails returns the details of the first error
+// visited by walk which has details. Errors without
+// details are skipped instead of calling their Details
+// method, so that cycles in the chain do not lead to an
+// endless recursion.
+func findDetails(err error, walk func(err error, fn WalkFunc)) (details any) {
+	walk(err, func(err error, _ int, _ []int) bool {
+		switch dErr := err.(type) {
+		case Error:
+			if dErr.details == nil {
+				return true
+			}
+			details = dErr.details.value
+		case HasDetails:
+			details = dErr.Details()
+		default:
+			return true
+		}
+		return false
+	})
+
+	return details
+}
+
+// WithDetails returns a copy of the error with the
+// given details attached. The original error is
+// left unchanged.
+func (t Error) WithDetails(details any) Error {
+	t.details = &detailsBox{value: details}
+	return t
+}
+
 // CallStack returns the errors CallStack
 // starting from where the Error
 // has been created.
+//
+// The CallStack is nil if the capture has been
+// disabled for the code of the Error via its
+// CapturePolicy.
 func (t Error) CallStack() *CallStack {
 	return t.callStack
 }
@@ -249,67 +446,233 @@ func (t Error) writeTitle(w io.Writer, withError bool) {
 	if t.message != "" {
 		fmt.Fprintf(w, " %s", t.message)
 	}
+	if t.internal != "" {
+		fmt.Fprintf(w, " [%s]", t.internal)
+	}
 	if withError && t.Inner != nil {
 		fmt.Fprintf(w, " (%s)", t.Inner)
 	}
 }
 
+func (t Error) title() string {
+	var b strings.Builder
+	t.writeTitle(&b, false)
+	return b.String()
+}
+
 func (t Error) writeStack(w io.Writer, stack int) {
 	t.writeTitle(w, false)
 
 	fmt.Fprintln(w)
 
-	if stack > 0 {
+	// We only want to print the last callstack in the error
+	// chain here.
+	if cs := lastCallStack(t); stack > 0 && cs != nil {
 		fmt.Fprint(w, "stack:\n")
+		cs.WriteIndent(w, stack, "  ")
+	}
 
-		// We only want to print the last callstack in the error
-		// chain here, so we unwrap the error until we found the
-		// last one which implements HasCallStack.
-		var (
-			e  error = t
-			cs *CallStack
-		)
-		for e != nil {
-			ecs, ok := e.(HasCallStack)
-			if !ok {
-				break
-			}
-			cs = ecs.CallStack()
-			e = errors.Unwrap(e)
-		}
+	if details := t.Details(); details != nil {
+		fmt.Fprintf(w, "details:\n  %+v\n", details)
+	}
 
-		cs.WriteIndent(w, stack, "  ")
+	writeAttrs(w, t.Attrs())
+
+	if tr := unwrapChain(t, func(error) bool { return true }); tr.truncated() {
+		fmt.Fprintf(w, "truncated:\n  %s\n", tr)
 	}
 
 	fmt.Fprintf(w, "inner error:\n  %s", t.Inner)
 }
 
+func (t Error) writeCausedBy(w io.Writer, max int) {
+	type layer struct {
+		err    error
+		frames []CallFrame
+		shared int
+	}
+
+	var layers []layer
+	tr := unwrapChain(t, func(err error) bool {
+		l := layer{err: err}
+		if cs, ok := err.(HasCallStack); ok {
+			l.frames = cs.CallStack().Filter()
+		}
+		layers = append(layers, l)
+		return true
+	})
+
+	// Each layer only shows the frames which are not
+	// shared with the nearest layer below with frames.
+	var below []CallFrame
+	for i := len(layers) - 1; i >= 0; i-- {
+		if len(layers[i].frames) == 0 {
+			continue
+		}
+		layers[i].shared = sharedFrames(layers[i].frames, below)
+		below = layers[i].frames
+	}
+
+	for i, l := range layers {
+		if i > 0 {
+			fmt.Fprint(w, "caused by: ")
+		}
+
+		if d, ok := l.err.(Error); ok {
+			d.writeTitle(w, false)
+		} else {
+			fmt.Fprintf(w, "%s: %s", reflect.TypeOf(l.err), l.err)
+		}
+		fmt.Fprintln(w)
+
+		frames := l.frames[:len(l.frames)-l.shared]
+		if max > 0 && len(frames) > max {
+			frames = frames[:max]
+		}
+
+		writeFrames(w, frames, "  ")
+
+		if more := len(l.frames) - len(frames); more > 0 {
+			fmt.Fprintf(w, "  ... %d more\n", more)
+		}
+	}
+
+	if tr.truncated() {
+		fmt.Fprintf(w, "caused by: ... %s\n", tr)
+	}
+}
+
 func (t Error) writeVerbose(w io.Writer, depth int) {
 	if depth == 0 {
 		depth = 1000
 	}
 
-	var err error = t
-	i := 0
+	// indents contains the indentation of the errors
+	// wrapped by each error on the current path, which
+	// is increased below each error wrapping multiple
+	// errors.
+	var indents []string
+	var multi []bool
+	var block bytes.Buffer
+
+	// prefix returns the indentation and the marker of the
+	// wrapped error with the given depth and path.
+	prefix := func(d int, path []int) (indent, marker string) {
+		if d > 0 {
+			indent = indents[d-1]
+			if multi[d-1] {
+				marker = fmt.Sprintf("[%d] ", path[d-1])
+			}
+		}
+		return indent, marker
+	}
+
+	visit := func(err error, d int, path []int) bool {
+		if d >= depth {
+			return true
+		}
 
-	for err != nil && i < depth {
-		if d, ok := err.(Error); ok {
-			d.writeTitle(w, false)
+		indent, marker := prefix(d, path)
 
-			fmt.Fprintln(w)
+		_, isMulti := err.(interface{ Unwrap() []error })
+		indents = append(indents[:d], indent)
+		multi = append(multi[:d], isMulti)
+		if isMulti {
+			indents[d] += "  "
+		}
 
-			if frame, ok := d.CallStack().At(0); ok {
-				fmt.Fprintf(w, "originated:\n  %s\n", frame)
+		block.Reset()
+		writeVerboseLayer(&block, err)
+
+		for i, line := range strings.SplitAfter(block.String(), "\n") {
+			if line == "" {
+				continue
 			}
-		} else {
-			fmt.Fprintf(w, "%+v\n", err)
+			fmt.Fprint(w, indent)
+			if i == 0 {
+				fmt.Fprint(w, marker)
+			}
+			fmt.Fprint(w, line)
 		}
 
-		fmt.Fprintf(w, "type:\n  %s\n", reflect.TypeOf(err))
+		return true
+	}
+
+	truncated := func(d int, path []int, tr truncation) {
+		if d >= depth {
+			return
+		}
+
+		indent, marker := prefix(d, path)
+		fmt.Fprintf(w, "%s%s... %s\n", indent, marker, tr)
+	}
+
+	walkTree(t, visit, truncated)
+}
+
+// writeVerboseLayer writes the verbose representation
+// of a single error in the tree without its wrapped
+// errors.
+func writeVerboseLayer(w io.Writer, err error) {
+	if d, ok := err.(Error); ok {
+		d.writeTitle(w, false)
+
+		fmt.Fprintln(w)
+
+		if frame, ok := d.CallStack().At(0); ok {
+			fmt.Fprintf(w, "originated:\n  %s\n", frame)
+		}
 
-		fmt.Fprintln(w, "----------")
+		if d.details != nil {
+			fmt.Fprintf(w, "details:\n  %+v\n", d.details.value)
+		}
 
-		err = errors.Unwrap(err)
-		i++
+		writeAttrs(w, d.attrs.list())
+	} else if multi, ok := err.(interface{ Unwrap() []error }); ok {
+		if c, ok := err.(HasCode); ok {
+			fmt.Fprintf(w, "<%s> ", c.Code())
+		}
+		fmt.Fprintf(w, "%d wrapped errors\n", len(multi.Unwrap()))
+	} else {
+		fmt.Fprintf(w, "%+v\n", err)
 	}
+
+	fmt.Fprintf(w, "type:\n  %s\n", reflect.TypeOf(err))
+
+	fmt.Fprintln(w, "----------")
+}
+
+// lastCallStack unwraps the given error until it found the
+// last one which implements HasCallStack and returns the
+// last non-nil CallStack in the chain.
+func lastCallStack(err error) *CallStack {
+	var cs *CallStack
+	unwrapChain(err, func(err error) bool {
+		ecs, ok := err.(HasCallStack)
+		if !ok {
+			return false
+		}
+		if c := ecs.CallStack(); c != nil {
+			cs = c
+		}
+		return true
+	})
+	return cs
+}
+
+// publicMessage returns the first non-empty public message
+// in the chain of the given error. The lookup stops at errors
+// with a code registered as internal.
+func publicMessage(err error) string {
+	var message string
+	unwrapChain(err, func(err error) bool {
+		if isInternal(err) {
+			return false
+		}
+		if m, ok := err.(HasMessage); ok {
+			message = m.Message()
+		}
+		return message == ""
+	})
+	return message
 }
@@ -2,6 +2,11 @@ package elk
 
 import (
 	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/studio-b12/elk/internal/assert"
@@ -23,6 +28,15 @@ func (t *customModelWithCodeAndMessage) Error() string   { return "customModelWi
 func (t *customModelWithCodeAndMessage) Code() ErrorCode { return t.code }
 func (t *customModelWithCodeAndMessage) Message() string { return t.message }
 
+type customModelWithCodeAndInner struct {
+	code  ErrorCode
+	inner error
+}
+
+func (t *customModelWithCodeAndInner) Error() string   { return "customModelWithCodeAndInner" }
+func (t *customModelWithCodeAndInner) Code() ErrorCode { return t.code }
+func (t *customModelWithCodeAndInner) Unwrap() error   { return t.inner }
+
 func TestCast(t *testing.T) {
 	const ErrCode = ErrorCode("some-error-code")
 
@@ -128,3 +142,300 @@ func TestCast(t *testing.T) {
 		assert.Equal(t, errMessage, castErr.Message())
 	})
 }
+
+type customModelWithDetails struct {
+	details any
+}
+
+func (t *customModelWithDetails) Error() string { return "customModelWithDetails" }
+func (t *customModelWithDetails) Details() any  { return t.details }
+
+func TestDetails(t *testing.T) {
+	const ErrCode = ErrorCode("some-error-code")
+
+	t.Run("none", func(t *testing.T) {
+		err := NewError(ErrCode)
+		assert.Equal(t, nil, err.Details())
+	})
+
+	t.Run("new", func(t *testing.T) {
+		err := NewErrorWithDetails(ErrCode, 123, "some message")
+		assert.Equal[any](t, 123, err.Details())
+		assert.Equal(t, "some message", err.Message())
+	})
+
+	t.Run("with", func(t *testing.T) {
+		err := NewError(ErrCode)
+		detailedErr := err.WithDetails("foo")
+		assert.Equal(t, nil, err.Details())
+		assert.Equal[any](t, "foo", detailedErr.Details())
+	})
+
+	t.Run("wrap", func(t *testing.T) {
+		err := WrapWithDetails(ErrCode, errors.New("some error"), "foo")
+		assert.Equal[any](t, "foo", err.Details())
+
+		err = Wrap(ErrCode, err)
+		assert.Equal[any](t, "foo", err.Details())
+
+		err = WrapCopyCode(err)
+		assert.Equal[any](t, "foo", err.Details())
+		assert.Equal(t, ErrCode, err.Code())
+
+		err = Wrap(ErrCode, err).WithDetails("bar")
+		assert.Equal[any](t, "bar", err.Details())
+	})
+
+	t.Run("cast", func(t *testing.T) {
+		err := Cast(&customModelWithDetails{details: "foo"})
+		assert.Equal[any](t, "foo", err.Details())
+	})
+
+	t.Run("comparable", func(t *testing.T) {
+		err := NewErrorWithDetails(ErrCode, map[string]int{"foo": 1})
+		assert.True(t, errors.Is(err, err))
+	})
+}
+
+func TestIs(t *testing.T) {
+	const (
+		ErrCode      = ErrorCode("some-error-code")
+		ErrOtherCode = ErrorCode("some-other-error-code")
+	)
+
+	var err error = NewError(ErrCode, "some message")
+	err = fmt.Errorf("wrapped: %w", err)
+	err = Wrap(ErrOtherCode, err)
+
+	assert.True(t, errors.Is(err, ErrCode.Sentinel()))
+	assert.True(t, errors.Is(err, ErrOtherCode.Sentinel()))
+	assert.True(t, errors.Is(err, NewError(ErrCode)))
+	assert.True(t, errors.Is(err, &customModelWithCode{code: ErrCode}))
+	assert.False(t, errors.Is(err, ErrorCode("unknown-code").Sentinel()))
+	assert.False(t, errors.Is(err, errors.New(string(ErrCode))))
+
+	err = errors.Join(errors.New("some error"), NewError(ErrCode))
+	assert.True(t, errors.Is(err, ErrCode.Sentinel()))
+}
+
+func TestInternalMessage(t *testing.T) {
+	const ErrCode = ErrorCode("some-error-code")
+
+	err := Wrap(ErrCode, errors.New("some error"), "Count could not be loaded").
+		WithInternalMessagef("failed getting count from database for id %d", 42)
+
+	assert.Equal(t, "Count could not be loaded", err.Message())
+	assert.Equal(t, "failed getting count from database for id 42", err.InternalMessage())
+
+	assert.Equal(t, "Count could not be loaded", fmt.Sprintf("%s", err))
+	assert.Equal(t,
+		"<some-error-code> Count could not be loaded "+
+			"[failed getting count from database for id 42] (some error)",
+		fmt.Sprintf("%v", err))
+	assert.True(t, strings.HasPrefix(fmt.Sprintf("%+v", err),
+		"<some-error-code> Count could not be loaded [failed getting count from database for id 42]\n"))
+	assert.True(t, strings.HasPrefix(fmt.Sprintf("%#v", err),
+		"<some-error-code> Count could not be loaded [failed getting count from database for id 42]\n"))
+
+	model := err.ToResponseModel(500)
+	assert.Equal(t, "Count could not be loaded", model.Message)
+
+	t.Run("internal-only", func(t *testing.T) {
+		err := NewError(ErrCode).WithInternalMessage("some internal message")
+		assert.Equal(t, "some-error-code", err.Error())
+		assert.Equal(t, "", err.ToResponseModel(500).Message)
+	})
+
+	t.Run("with-message", func(t *testing.T) {
+		err := NewError(ErrCode, "foo")
+		assert.Equal(t, "bar", err.WithMessage("bar").Message())
+		assert.Equal(t, "foo", err.Message())
+	})
+
+	t.Run("propagate", func(t *testing.T) {
+		inner := NewError(ErrCode, "public message").WithInternalMessage("internal message")
+
+		copied := WrapCopyCode(inner)
+		assert.Equal(t, "public message", copied.Message())
+		assert.Equal(t, "", copied.InternalMessage())
+
+		copied = WrapCopyCode(inner, "other message")
+		assert.Equal(t, "other message", copied.Message())
+
+		cast := Cast(fmt.Errorf("wrapped: %w", inner))
+		assert.Equal(t, CodeUnexpected, cast.Code())
+		assert.Equal(t, "", cast.Message())
+		assert.Equal(t, "", cast.ToResponseModel(0).Message)
+
+		copied = WrapCopyCode(fmt.Errorf("wrapped: %w", inner))
+		assert.Equal(t, CodeUnexpected, copied.Code())
+		assert.Equal(t, "", copied.Message())
+
+		cast = Cast(&customModelWithCodeAndInner{code: ErrCode, inner: inner})
+		assert.Equal(t, ErrCode, cast.Code())
+		assert.Equal(t, "public message", cast.Message())
+	})
+
+	t.Run("internal", func(t *testing.T) {
+		inner := NewError(errInternal, "internal message")
+
+		cast := Cast(&customModelWithCodeAndInner{code: ErrCode, inner: inner})
+		assert.Equal(t, ErrCode, cast.Code())
+		assert.Equal(t, "", cast.Message())
+
+		copied := WrapCopyCode(Wrap(ErrCode, inner))
+		assert.Equal(t, "", copied.Message())
+	})
+}
+
+func BenchmarkNewError(b *testing.B) {
+	const ErrCode = ErrorCode("some-error-code")
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = NewError(ErrCode, "some message")
+	}
+}
+
+func BenchmarkWrap(b *testing.B) {
+	const ErrCode = ErrorCode("some-error-code")
+	err := errors.New("some error")
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = Wrap(ErrCode, err, "some message")
+	}
+}
+
+func BenchmarkFormatStack(b *testing.B) {
+	const ErrCode = ErrorCode("some-error-code")
+	err := errors.New("some error")
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = fmt.Sprintf("%+v", Wrap(ErrCode, err, "some message"))
+	}
+}
+
+// This test formats, serializes and inspects a shared error
+// from many goroutines concurrently. It must be run with the
+// race detector to be meaningful.
+func TestErrorConcurrentUse(t *testing.T) {
+	const ErrCode = ErrorCode("some-error-code")
+
+	// The frames of the call stack must not be
+	// resolved before the goroutines are started.
+	inner := NewErrorWithDetails(ErrCode, map[string]int{"limit": 10}, "some message")
+	err := Wrap(ErrCode, fmt.Errorf("wrapped: %w", inner)).With("id", 42)
+
+	enc := Encoder{Debug: true}
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	const n = 16
+	start := make(chan struct{})
+	results := make(chan string, n)
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			var b strings.Builder
+			fmt.Fprintf(&b, "%s|%v|%+v|%#v", err, err, err, err)
+
+			data, jErr := enc.Json(err, 500)
+			if jErr != nil {
+				t.Error(jErr)
+			}
+			b.Write(data)
+
+			data, dErr := MarshalDiagnostic(err)
+			if dErr != nil {
+				t.Error(dErr)
+			}
+			b.Write(data)
+
+			logger.Error("failed", "err", err)
+
+			frame, _ := inner.CallStack().First()
+			b.WriteString(frame)
+			b.WriteString(inner.CallStack().String())
+
+			raw, _ := err.CallStack().Raw().MarshalText()
+			b.Write(raw)
+
+			if !errors.Is(err, ErrCode.Sentinel()) || !IsCode(err, ErrCode) {
+				t.Error("error code does not match")
+			}
+			if _, ok := As[Error](err.Inner); !ok {
+				t.Error("inner error is not found")
+			}
+			b.WriteString(fmt.Sprint(err.Details(), err.Attrs()))
+
+			results <- b.String()
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+	close(results)
+
+	first := <-results
+	assert.True(t, strings.Contains(first, "TestErrorConcurrentUse"))
+	for res := range results {
+		assert.Equal(t, first, res)
+	}
+}
+
+func causedByInner() error {
+	return NewError("inner-error", "inner message")
+}
+
+func causedByMiddle() error {
+	err := causedByInner()
+	return Wrap("middle-error", fmt.Errorf("wrapped: %w", err))
+}
+
+func TestCausedBy(t *testing.T) {
+	middle := causedByMiddle()
+	err := Wrap("outer-error", middle)
+
+	lines := strings.Split(fmt.Sprintf("%+#v", err), "\n")
+	prefixes := []string{
+		"<outer-error>",
+		"  github.com/studio-b12/elk.TestCausedBy",
+		"  ... 2 more",
+		"caused by: <middle-error>",
+		"  github.com/studio-b12/elk.causedByMiddle",
+		"  ... 3 more",
+		"caused by: *fmt.wrapError: wrapped: <inner-error> inner message",
+		"caused by: <inner-error> inner message",
+		"  github.com/studio-b12/elk.causedByInner",
+		"  github.com/studio-b12/elk.causedByMiddle",
+		"  github.com/studio-b12/elk.TestCausedBy",
+		"  testing.tRunner",
+		"  runtime.goexit",
+		"caused by: *errors.errorString: inner-error",
+		"",
+	}
+
+	assert.Equal(t, len(prefixes), len(lines))
+	for i, prefix := range prefixes {
+		assert.True(t, strings.HasPrefix(lines[i], prefix))
+	}
+
+	t.Run("precision", func(t *testing.T) {
+		lines := strings.Split(fmt.Sprintf("%+#.1v", err), "\n")
+		assert.Equal(t, "  ... 4 more", lines[9])
+	})
+
+	// Decoded frames have no program counters, so
+	// they are compared by function, file and line.
+	t.Run("decoded", func(t *testing.T) {
+		data, _ := MarshalDiagnostic(err)
+		decoded, _ := UnmarshalDiagnostic(data)
+		assert.Equal(t, len(prefixes), len(strings.Split(fmt.Sprintf("%+#v", decoded), "\n")))
+	})
+}
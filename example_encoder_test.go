@@ -0,0 +1,43 @@
+package elk_test
+
+import (
+	"os"
+
+	"github.com/studio-b12/elk"
+)
+
+func ExampleEncoder() {
+	enc := elk.Encoder{
+		Naming:   elk.CamelCase,
+		Envelope: "error",
+		Extra: map[string]any{
+			"apiVersion": "v2",
+		},
+	}
+
+	err := elk.NewErrorWithDetails("some-error-code",
+		map[string]any{"Limit": 10},
+		"some message")
+
+	_ = enc.Encode(os.Stdout, err, 400)
+
+	enc.Compact = true
+	enc.Naming = elk.SnakeCase
+	enc.Envelope = ""
+	enc.Extra = nil
+	_ = enc.Encode(os.Stdout, err, 400)
+
+	// Output:
+	// {
+	//   "error": {
+	//     "code": "some-error-code",
+	//     "message": "some message",
+	//     "status": 400,
+	//     "details": {
+	//       "Limit": 10
+	//     }
+	//   },
+	//   "apiVersion": "v2"
+	// }
+	// {"code":"some-error-code","message":"some message","status":400,"details":{"Limit":10}}
+}
@@ -0,0 +1,46 @@
+package elk_test
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/studio-b12/elk"
+)
+
+func ExampleProblemEncoder() {
+	const ErrQuotaExceeded = elk.ErrorCode("quota-exceeded")
+
+	enc := elk.ProblemEncoder{
+		BaseURI: "https://example.com/problems/",
+		Instance: func(err elk.Error) string {
+			return "/devices/42"
+		},
+	}
+
+	err := elk.WrapWithDetails(ErrQuotaExceeded, errors.New("some error"),
+		map[string]int{"limit": 10, "used": 12},
+		"the device quota has been exceeded")
+
+	json, _ := enc.Json(err, 429)
+	fmt.Println(string(json))
+
+	decoded, _ := enc.Decode(json)
+	fmt.Println(decoded.Code())
+	fmt.Println(decoded.Message())
+	fmt.Println(decoded.Details())
+
+	// Output:
+	// {
+	//   "code": "quota-exceeded",
+	//   "detail": "the device quota has been exceeded",
+	//   "instance": "/devices/42",
+	//   "limit": 10,
+	//   "status": 429,
+	//   "title": "Too Many Requests",
+	//   "type": "https://example.com/problems/quota-exceeded",
+	//   "used": 12
+	// }
+	// quota-exceeded
+	// the device quota has been exceeded
+	// map[limit:10 used:12]
+}
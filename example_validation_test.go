@@ -0,0 +1,59 @@
+package elk_test
+
+import (
+	"fmt"
+
+	"github.com/studio-b12/elk"
+)
+
+func ExampleValidation() {
+	const (
+		ErrRequired    = elk.ErrorCode("required")
+		ErrOutOfBounds = elk.ErrorCode("out-of-bounds")
+	)
+
+	type Item struct {
+		Name  string
+		Price int
+	}
+
+	items := []Item{
+		{Name: "foo", Price: 10},
+		{Name: "", Price: -5},
+	}
+
+	v := elk.NewValidation()
+	for i, item := range items {
+		iv := v.Field("items").Index(i)
+		iv.Check(item.Name != "", "name", ErrRequired, "name is required")
+		if item.Price < 0 {
+			iv.AddParams("price", ErrOutOfBounds, "price must not be negative",
+				map[string]any{"min": 0})
+		}
+	}
+
+	err := v.Err()
+	fmt.Println(string(elk.MustJson(err, 400)))
+
+	// Output:
+	// {
+	//   "Code": "validation-failed",
+	//   "Message": "validation failed",
+	//   "Status": 400,
+	//   "Details": [
+	//     {
+	//       "Field": "items[1].name",
+	//       "Code": "required",
+	//       "Message": "name is required"
+	//     },
+	//     {
+	//       "Field": "items[1].price",
+	//       "Code": "out-of-bounds",
+	//       "Message": "price must not be negative",
+	//       "Params": {
+	//         "min": 0
+	//       }
+	//     }
+	//   ]
+	// }
+}
@@ -0,0 +1,45 @@
+package elk_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/studio-b12/elk"
+)
+
+func ExampleWalk() {
+	err := errors.Join(
+		errors.New("first"),
+		fmt.Errorf("wrapped: %w", errors.New("second")),
+	)
+
+	elk.Walk(err, func(err error, depth int, path []int) bool {
+		if depth > 0 {
+			fmt.Printf("%s%v %s\n", strings.Repeat("  ", depth), path, err)
+		}
+		return true
+	})
+
+	// Output:
+	//   [0] first
+	//   [1] wrapped: second
+	//     [1 0] second
+}
+
+func ExampleLeaves() {
+	const ErrNotFound = elk.ErrorCode("not-found")
+
+	err := errors.Join(
+		elk.Wrap(ErrNotFound, errors.New("user not found")),
+		errors.New("connection lost"),
+	)
+
+	for _, leaf := range elk.Leaves(err) {
+		fmt.Println(leaf)
+	}
+
+	// Output:
+	// user not found
+	// connection lost
+}
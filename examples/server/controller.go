@@ -23,7 +23,8 @@ func (t *Controller) GetCount(id string) (Count, error) {
 
 	count, ok, err := t.db.GetCount(id)
 	if err != nil {
-		return Count{}, elk.Wrap(ErrorInternal, err, "failed getting count from database")
+		return Count{}, elk.Wrap(ErrorInternal, err, "The count could not be loaded.").
+			WithInternalMessagef("failed getting count %q from database", id)
 	}
 
 	if !ok {
@@ -43,14 +44,16 @@ func (t *Controller) IncrementCount(id string) (Count, error) {
 
 	count, _, err := t.db.GetCount(id)
 	if err != nil {
-		return Count{}, elk.Wrap(ErrorInternal, err, "failed getting count from database")
+		return Count{}, elk.Wrap(ErrorInternal, err, "The count could not be loaded.").
+			WithInternalMessagef("failed getting count %q from database", id)
 	}
 
 	count++
 
 	err = t.db.SetCount(id, count)
 	if err != nil {
-		return Count{}, elk.Wrap(ErrorInternal, err, "failed setting count to database")
+		return Count{}, elk.Wrap(ErrorInternal, err, "The count could not be updated.").
+			WithInternalMessagef("failed setting count %q to database", id)
 	}
 
 	c := Count{
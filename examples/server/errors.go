@@ -1,8 +1,23 @@
 package main
 
-import "github.com/studio-b12/elk"
+import (
+	"net/http"
 
-const (
-	ErrorInternal      = elk.ErrorCode("internal-server-error")
-	ErrorCountNotFound = elk.ErrorCode("count-not-found")
+	"github.com/studio-b12/elk"
+)
+
+var (
+	ErrorInternal = elk.MustRegister("internal-server-error", elk.CodeInfo{
+		Status:      http.StatusInternalServerError,
+		Description: "An internal server error occurred.",
+		Severity:    elk.SeverityError,
+	})
+	ErrorBadRequest = elk.MustRegister("bad-request", elk.CodeInfo{
+		Status:      http.StatusBadRequest,
+		Description: "The request is invalid.",
+	})
+	ErrorCountNotFound = elk.MustRegister("count-not-found", elk.CodeInfo{
+		Status:      http.StatusNotFound,
+		Description: "The count could not be found.",
+	})
 )
@@ -2,81 +2,79 @@ package main
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
+	"os"
 	"strings"
 
 	"github.com/studio-b12/elk"
+	"github.com/studio-b12/elk/elkhttp"
 )
 
 func main() {
+	logHandler := elk.NewSlogHandler(slog.NewTextHandler(os.Stderr, nil), nil)
+	slog.SetDefault(slog.New(logHandler))
+
 	db := NewDatabase("db.json")
 	ctl := NewController(db)
 
+	// The responder maps the error codes returned by the handlers
+	// to the HTTP status codes registered with the codes (see
+	// errors.go). Errors responded with a 5xx status code are
+	// logged with their call stack.
+	responder := &elkhttp.Responder{
+		StackDepth: 5,
+	}
+
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("/count", handleCount(ctl))
+	mux.Handle("/count", responder.Handler(handleCount(ctl)))
 
 	_ = http.ListenAndServe(":8080", mux)
 }
 
-func handleCount(ctl *Controller) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
+func handleCount(ctl *Controller) elkhttp.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
 		switch strings.ToUpper(r.Method) {
 		case "GET":
-			handleGetCount(ctl, w, r)
+			return handleGetCount(ctl, w, r)
 		case "POST":
-			handlePostCount(ctl, w, r)
+			return handlePostCount(ctl, w, r)
 		default:
 			w.WriteHeader(http.StatusMethodNotAllowed)
+			return nil
 		}
 	}
 }
 
-func handleGetCount(ctl *Controller, w http.ResponseWriter, r *http.Request) {
+func handleGetCount(ctl *Controller, w http.ResponseWriter, r *http.Request) error {
 	id := r.URL.Query().Get("id")
 	if id == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		return
+		return elk.NewError(ErrorBadRequest, "no id has been specified")
 	}
 
 	res, err := ctl.GetCount(id)
 	if err != nil {
-		var status int
-		switch elk.Cast(err).Code() {
-		case ErrorCountNotFound:
-			status = http.StatusNotFound
-		default:
-			log.Printf("error: %+.5v\n", err)
-			status = http.StatusInternalServerError
-		}
-		w.WriteHeader(status)
-		_, _ = w.Write(elk.MustJson(err, status))
-		return
+		return err
 	}
 
 	d, _ := json.MarshalIndent(res, "", "  ")
 	_, _ = w.Write(d)
+	return nil
 }
 
-func handlePostCount(ctl *Controller, w http.ResponseWriter, r *http.Request) {
+func handlePostCount(ctl *Controller, w http.ResponseWriter, r *http.Request) error {
 	id := r.URL.Query().Get("id")
 	if id == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		return
+		return elk.NewError(ErrorBadRequest, "no id has been specified")
 	}
 
 	res, err := ctl.IncrementCount(id)
 	if err != nil {
-		switch elk.Cast(err).Code() {
-		default:
-			log.Printf("error: %#.5v\n", err)
-			w.WriteHeader(http.StatusInternalServerError)
-		}
-		_, _ = w.Write(elk.MustJson(err, http.StatusInternalServerError))
-		return
+		return err
 	}
 
 	d, _ := json.MarshalIndent(res, "", "  ")
 	_, _ = w.Write(d)
+	return nil
 }
@@ -1,3 +1,3 @@
 module github.com/studio-b12/elk
 
-go 1.20
+go 1.21
@@ -0,0 +1,145 @@
+package elk
+
+import (
+	"context"
+	"sync"
+)
+
+// Group runs functions concurrently and collects
+// their errors. Panics in the functions are recovered
+// and turned into Errors via RecoverValue.
+//
+// By default, Wait returns the first error returned
+// by any of the functions. When collecting all errors
+// is enabled via SetCollectAll, the errors of all
+// functions are returned instead.
+//
+// The zero value is ready to use.
+type Group struct {
+	cancel context.CancelCauseFunc
+
+	wg  sync.WaitGroup
+	sem chan struct{}
+
+	collectAll bool
+
+	mtx  sync.Mutex
+	errs []error
+}
+
+// GroupWithContext returns a new Group and a derived
+// context which is canceled when the first function
+// returns an error or when Wait returns, whichever
+// occurs first.
+func GroupWithContext(ctx context.Context) (*Group, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &Group{cancel: cancel}, ctx
+}
+
+// SetLimit limits the number of concurrently running
+// functions to n. A negative value removes the limit.
+//
+// SetLimit must not be called while functions of the
+// group are running.
+func (t *Group) SetLimit(n int) {
+	if n < 0 {
+		t.sem = nil
+		return
+	}
+	t.sem = make(chan struct{}, n)
+}
+
+// SetCollectAll defines whether Wait returns the errors
+// of all functions instead of only the first one. The
+// collected errors which are not of type Error are casted
+// in the goroutine of their function, so that their call
+// stack starts there instead of in Wait.
+//
+// SetCollectAll must not be called while functions of
+// the group are running.
+func (t *Group) SetCollectAll(collectAll bool) {
+	t.collectAll = collectAll
+}
+
+// Go runs the given function in a new goroutine. If a
+// limit is set, Go blocks until the function can be
+// run without exceeding the limit.
+func (t *Group) Go(f func() error) {
+	if t.sem != nil {
+		t.sem <- struct{}{}
+	}
+
+	t.wg.Add(1)
+	go func() {
+		defer t.done()
+
+		err := t.run(f)
+		if err == nil {
+			return
+		}
+
+		if _, ok := err.(*MultiError); t.collectAll && !ok {
+			// Errors are casted here instead of in Wait, so
+			// that the call stack of plain errors points into
+			// this goroutine.
+			err = cast(0, err, CodeUnexpected)
+		}
+
+		t.addError(err)
+	}()
+}
+
+// Wait blocks until all functions have returned and
+// returns either the first error or, if collecting all
+// errors is enabled, a *MultiError containing all errors.
+// If no function failed, nil is returned.
+func (t *Group) Wait() error {
+	t.wg.Wait()
+
+	if t.cancel != nil {
+		t.cancel(nil)
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if len(t.errs) == 0 {
+		return nil
+	}
+
+	if !t.collectAll {
+		return t.errs[0]
+	}
+
+	return NewMultiError(t.errs...)
+}
+
+func (t *Group) run(f func() error) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = RecoverValue(v)
+		}
+	}()
+
+	return f()
+}
+
+func (t *Group) done() {
+	if t.sem != nil {
+		<-t.sem
+	}
+	t.wg.Done()
+}
+
+func (t *Group) addError(err error) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if len(t.errs) == 0 && t.cancel != nil {
+		t.cancel(err)
+	}
+
+	if len(t.errs) == 0 || t.collectAll {
+		t.errs = append(t.errs, err)
+	}
+}
@@ -0,0 +1,120 @@
+package elk
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/studio-b12/elk/internal/assert"
+)
+
+func TestGroup(t *testing.T) {
+	const (
+		ErrCode      = ErrorCode("some-error-code")
+		ErrOtherCode = ErrorCode("some-other-error-code")
+	)
+
+	t.Run("no-error", func(t *testing.T) {
+		var g Group
+		var n atomic.Int32
+		for i := 0; i < 10; i++ {
+			g.Go(func() error {
+				n.Add(1)
+				return nil
+			})
+		}
+
+		assert.Nil(t, g.Wait())
+		assert.Equal(t, int32(10), n.Load())
+	})
+
+	t.Run("first-error", func(t *testing.T) {
+		var g Group
+		g.Go(func() error { return NewError(ErrCode) })
+
+		err := g.Wait()
+		assert.Equal(t, ErrCode, Cast(err).Code())
+	})
+
+	t.Run("collect-all", func(t *testing.T) {
+		var g Group
+		g.SetCollectAll(true)
+		g.Go(func() error { return NewError(ErrCode) })
+		g.Go(func() error { return NewError(ErrOtherCode) })
+		g.Go(func() error { return nil })
+
+		err := g.Wait()
+		m, ok := err.(*MultiError)
+		assert.True(t, ok)
+		assert.Equal(t, 2, m.Len())
+		assert.True(t, ContainsCode(err, ErrCode))
+		assert.True(t, ContainsCode(err, ErrOtherCode))
+	})
+
+	t.Run("collect-all-plain", func(t *testing.T) {
+		var g Group
+		g.SetCollectAll(true)
+		g.Go(func() error { return errors.New("some error") })
+
+		err := g.Wait()
+		m, ok := err.(*MultiError)
+		assert.True(t, ok)
+		assert.Equal(t, 1, m.Len())
+
+		frame, _ := m.Errors()[0].CallStack().First()
+		assert.True(t, strings.HasPrefix(frame, "github.com/studio-b12/elk.(*Group).Go.func1 "))
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		var g Group
+		g.Go(func() error {
+			panicWithValue("oh no")
+			return nil
+		})
+
+		err := g.Wait()
+		assert.Equal(t, CodeUnexpected, Cast(err).Code())
+		frame, _ := Cast(err).CallStack().First()
+		assert.True(t, strings.HasPrefix(frame, "github.com/studio-b12/elk.panicWithValue "))
+	})
+
+	t.Run("context", func(t *testing.T) {
+		g, ctx := GroupWithContext(context.Background())
+		g.Go(func() error { return NewError(ErrCode) })
+		g.Go(func() error {
+			<-ctx.Done()
+			return nil
+		})
+
+		err := g.Wait()
+		assert.Equal(t, ErrCode, Cast(err).Code())
+		assert.True(t, errors.Is(context.Cause(ctx), ErrCode.Sentinel()))
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		var g Group
+		g.SetLimit(2)
+
+		var running, maxRunning atomic.Int32
+		for i := 0; i < 10; i++ {
+			g.Go(func() error {
+				n := running.Add(1)
+				for {
+					m := maxRunning.Load()
+					if n <= m || maxRunning.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				running.Add(-1)
+				return nil
+			})
+		}
+
+		assert.Nil(t, g.Wait())
+		assert.True(t, maxRunning.Load() <= 2)
+	})
+}
@@ -20,6 +20,17 @@ type HasMessage interface {
 	Message() string
 }
 
+// HasInternalMessage describes an error which
+// has an additional internal message, which must
+// not be exposed to clients.
+type HasInternalMessage interface {
+	error
+
+	// InternalMessage returns the value for
+	// the internal message.
+	InternalMessage() string
+}
+
 // HasCode describes an error which has an
 // ErrorCode.
 type HasCode interface {
@@ -30,9 +41,13 @@ type HasCode interface {
 	Code() ErrorCode
 }
 
+// HasDetails describes an error which has
+// additional details.
 type HasDetails interface {
 	error
 
+	// Details returns the details value
+	// of the error.
 	Details() any
 }
 
@@ -27,7 +27,7 @@ func Equal[T comparable](t *testing.T, expected T, actual T) {
 func Nil(t *testing.T, value any) {
 	t.Helper()
 
-	if !reflect.ValueOf(value).IsNil() {
+	if value != nil && !reflect.ValueOf(value).IsNil() {
 		fail(t, nil, value)
 	}
 }
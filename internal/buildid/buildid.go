@@ -0,0 +1,56 @@
+// Package buildid reads the Go build ID of ELF binaries.
+package buildid
+
+import (
+	"debug/elf"
+	"strings"
+)
+
+// noteType is the type of the ELF note which
+// contains the Go build ID.
+const noteType = 4
+
+// Read returns the Go build ID recorded in the
+// .note.go.buildid section of the given ELF file.
+// An empty string is returned if there is none.
+func Read(f *elf.File) string {
+	s := f.Section(".note.go.buildid")
+	if s == nil {
+		return ""
+	}
+
+	data, err := s.Data()
+	if err != nil || len(data) < 12 {
+		return ""
+	}
+
+	order := f.ByteOrder
+	nameSize := order.Uint32(data[0:])
+	descSize := order.Uint32(data[4:])
+	typ := order.Uint32(data[8:])
+
+	nameEnd := 12 + align4(uint64(nameSize))
+	if typ != noteType || nameEnd+uint64(descSize) > uint64(len(data)) ||
+		strings.TrimRight(string(data[12:12+nameSize]), "\x00") != "Go" {
+		return ""
+	}
+
+	return string(data[nameEnd : nameEnd+uint64(descSize)])
+}
+
+// ReadFile returns the Go build ID of the ELF
+// binary at the given path. An empty string is
+// returned if it can not be read.
+func ReadFile(path string) string {
+	f, err := elf.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	return Read(f)
+}
+
+func align4(n uint64) uint64 {
+	return (n + 3) &^ 3
+}
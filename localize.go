@@ -0,0 +1,417 @@
+package elk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Plural categories used as keys of a Message.
+const (
+	PluralZero  = "zero"
+	PluralOne   = "one"
+	PluralOther = "other"
+)
+
+// Message is a localized message template keyed by
+// plural category. Templates can contain placeholders
+// in the form of `{name}`, which are replaced with the
+// attributes of the localized error.
+//
+// When decoded from JSON, a message can either be a
+// single template string, which is used as the "other"
+// category, or an object mapping plural categories to
+// templates.
+type Message map[string]string
+
+// UnmarshalJSON decodes a message from either a single
+// template string or an object of plural categories.
+func (t *Message) UnmarshalJSON(data []byte) error {
+	var s string
+	if json.Unmarshal(data, &s) == nil {
+		*t = Message{PluralOther: s}
+		return nil
+	}
+
+	var m map[string]string
+	err := json.Unmarshal(data, &m)
+	if err != nil {
+		return err
+	}
+
+	*t = m
+	return nil
+}
+
+// Catalog contains the localized messages of one
+// language keyed by ErrorCode.
+type Catalog struct {
+	Messages map[ErrorCode]Message
+
+	// PluralRule returns the plural category for the
+	// given count. If not set, "one" is returned for
+	// 1 and "other" for any other count. The category
+	// "zero" is always used for 0 if the message has
+	// a template for it.
+	PluralRule func(n float64) string
+}
+
+func (t *Catalog) pluralCategory(n float64) string {
+	if t.PluralRule != nil {
+		return t.PluralRule(n)
+	}
+	if n == 1 {
+		return PluralOne
+	}
+	return PluralOther
+}
+
+// Localizer contains message Catalogs for multiple
+// languages and localizes Errors by their code.
+//
+// A Localizer is safe for concurrent use.
+type Localizer struct {
+	mtx      sync.RWMutex
+	catalogs map[string]*Catalog
+}
+
+// DefaultLocalizer is the Localizer used by
+// ToLocalizedResponseModel, JsonLocalized and
+// JsonContext.
+var DefaultLocalizer = NewLocalizer()
+
+// NewLocalizer returns a new Localizer without any
+// catalogs.
+func NewLocalizer() *Localizer {
+	return &Localizer{
+		catalogs: make(map[string]*Catalog),
+	}
+}
+
+// Register sets the catalog for the given language
+// tag, i.e. "de" or "en-US". Previously registered
+// catalogs for the same language are replaced.
+func (t *Localizer) Register(lang string, catalog Catalog) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.catalogs[normalizeLanguage(lang)] = &catalog
+}
+
+// LoadJson decodes a catalog from the given reader
+// and registers it for the given language.
+//
+// The JSON document must be an object mapping error
+// codes to messages. See Message for more
+// information.
+func (t *Localizer) LoadJson(lang string, r io.Reader) error {
+	var messages map[ErrorCode]Message
+	err := json.NewDecoder(r).Decode(&messages)
+	if err != nil {
+		return Wrapf(CodeUnexpected, err, "failed decoding catalog for language %q", lang)
+	}
+
+	t.Register(lang, Catalog{Messages: messages})
+	return nil
+}
+
+// LoadFS loads the catalogs from all files in fsys
+// matching the given pattern (see fs.Glob). The
+// language of each catalog is the name of the file
+// without extension, i.e. "de.json" or "en-US.json".
+//
+// This can be used to load catalogs from an embed.FS.
+//
+//	//go:embed locales/*.json
+//	var locales embed.FS
+//
+//	err := elk.DefaultLocalizer.LoadFS(locales, "locales/*.json")
+func (t *Localizer) LoadFS(fsys fs.FS, pattern string) error {
+	names, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return Wrap(CodeUnexpected, err, "invalid catalog file pattern")
+	}
+
+	for _, name := range names {
+		f, err := fsys.Open(name)
+		if err != nil {
+			return Wrapf(CodeUnexpected, err, "failed opening catalog file %q", name)
+		}
+
+		base := path.Base(name)
+		err = t.LoadJson(strings.TrimSuffix(base, path.Ext(base)), f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Localize returns the message for the given code in the
+// first of the given languages for which a message is
+// available. If a language tag with region has no
+// catalog, the catalog of the base language is used,
+// i.e. "de" for "de-AT".
+//
+// Placeholders in the message are replaced with the
+// given params. If params contains a numeric "count"
+// parameter, it is used to select the plural form.
+func (t *Localizer) Localize(code ErrorCode, params map[string]any, langs ...string) (string, bool) {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
+	for _, lang := range langs {
+		catalog, ok := t.lookup(lang)
+		if !ok {
+			continue
+		}
+
+		msg, ok := catalog.Messages[code]
+		if !ok {
+			continue
+		}
+
+		tmpl, ok := msg[PluralOther]
+		if count, isNum := toFloat(params["count"]); isNum {
+			category := catalog.pluralCategory(count)
+			if _, hasZero := msg[PluralZero]; hasZero && count == 0 {
+				category = PluralZero
+			}
+			if form, hasForm := msg[category]; hasForm {
+				tmpl, ok = form, true
+			}
+		}
+
+		if !ok {
+			continue
+		}
+
+		return expandTemplate(tmpl, params), true
+	}
+
+	return "", false
+}
+
+func (t *Localizer) lookup(lang string) (*Catalog, bool) {
+	lang = normalizeLanguage(lang)
+
+	if catalog, ok := t.catalogs[lang]; ok {
+		return catalog, true
+	}
+
+	if base, _, ok := strings.Cut(lang, "-"); ok {
+		catalog, ok := t.catalogs[base]
+		return catalog, ok
+	}
+
+	return nil, false
+}
+
+// LocalizeError returns the localized message of the
+// given error. The attributes of the error are used as
+// parameters. If no localized message is available,
+// the message of the error is returned.
+func (t *Localizer) LocalizeError(err Error, langs ...string) string {
+	if msg, ok := t.Localize(err.Code(), attrParams(err.Attrs()), langs...); ok {
+		return msg
+	}
+	return err.Message()
+}
+
+// ResponseModel transforms the error into an ErrorResponseModel
+// like ToResponseModel but with the message localized in the
+// first matching language of langs. Messages of validation
+// entries contained in the details are localized as well, using
+// their params as parameters.
+func (t *Localizer) ResponseModel(err Error, statusCode int, langs ...string) ErrorResponseModel {
+	model := err.ToResponseModel(statusCode)
+	if model.Code != err.Code() {
+		// The code has been masked because it is internal,
+		// so the message must not be set.
+		return model
+	}
+
+	model.Message = t.LocalizeError(err, langs...)
+
+	if entries, ok := model.Details.([]ValidationEntry); ok {
+		localized := make([]ValidationEntry, len(entries))
+		for i, entry := range entries {
+			if msg, ok := t.Localize(entry.Code, entry.Params, langs...); ok {
+				entry.Message = msg
+			}
+			localized[i] = entry
+		}
+		model.Details = localized
+	}
+
+	return model
+}
+
+// ToLocalizedResponseModel is shorthand for
+// DefaultLocalizer.ResponseModel.
+func (t Error) ToLocalizedResponseModel(statusCode int, langs ...string) ErrorResponseModel {
+	return DefaultLocalizer.ResponseModel(t, statusCode, langs...)
+}
+
+// JsonLocalized behaves like Json but localizes the message
+// of the error using the DefaultLocalizer in the language
+// picked from the given Accept-Language header value.
+// The DefaultEncoder is used to encode the error.
+func JsonLocalized(err error, statusCode int, acceptLanguage string) ([]byte, error) {
+	return DefaultEncoder.JsonLocalized(err, statusCode, ParseAcceptLanguage(acceptLanguage)...)
+}
+
+// JsonContext behaves like Json but localizes the message
+// of the error using the DefaultLocalizer in the languages
+// attached to the context via ContextWithLanguages.
+// The DefaultEncoder is used to encode the error.
+func JsonContext(ctx context.Context, err error, statusCode int) ([]byte, error) {
+	return DefaultEncoder.JsonLocalized(err, statusCode, LanguagesFromContext(ctx)...)
+}
+
+type languagesKey struct{}
+
+// ContextWithLanguages returns a copy of ctx with the given
+// preferred languages attached.
+func ContextWithLanguages(ctx context.Context, langs ...string) context.Context {
+	return context.WithValue(ctx, languagesKey{}, langs)
+}
+
+// LanguagesFromContext returns the preferred languages
+// attached to the context via ContextWithLanguages.
+func LanguagesFromContext(ctx context.Context) []string {
+	langs, _ := ctx.Value(languagesKey{}).([]string)
+	return langs
+}
+
+// ParseAcceptLanguage returns the language tags of the
+// given Accept-Language header value ordered by their
+// quality values. Wildcards and languages with a
+// quality value of 0 are omitted.
+func ParseAcceptLanguage(header string) []string {
+	type entry struct {
+		lang string
+		q    float64
+	}
+
+	var entries []entry
+	for _, part := range strings.Split(header, ",") {
+		lang, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang = strings.TrimSpace(lang)
+		if lang == "" || lang == "*" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			k, v, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if k == "q" {
+				if pq, err := strconv.ParseFloat(v, 64); err == nil {
+					q = pq
+				}
+			}
+		}
+
+		if q > 0 {
+			entries = append(entries, entry{lang: lang, q: q})
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].q > entries[j].q
+	})
+
+	langs := make([]string, len(entries))
+	for i, e := range entries {
+		langs[i] = e.lang
+	}
+
+	return langs
+}
+
+func normalizeLanguage(lang string) string {
+	return strings.ToLower(strings.ReplaceAll(lang, "_", "-"))
+}
+
+// attrParams transforms the given attributes into a
+// parameter map. If multiple attributes have the same
+// key, the first one wins.
+func attrParams(attrs []Attr) map[string]any {
+	params := make(map[string]any, len(attrs))
+	for _, attr := range attrs {
+		if _, ok := params[attr.Key]; !ok {
+			params[attr.Key] = attr.Value
+		}
+	}
+	return params
+}
+
+// expandTemplate replaces all placeholders in the form
+// of `{name}` in tmpl with the corresponding params.
+// Placeholders without parameter are kept as is.
+func expandTemplate(tmpl string, params map[string]any) string {
+	var b strings.Builder
+
+	for {
+		start := strings.IndexByte(tmpl, '{')
+		if start < 0 {
+			break
+		}
+
+		end := strings.IndexByte(tmpl[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+
+		b.WriteString(tmpl[:start])
+		if v, ok := params[tmpl[start+1:end]]; ok {
+			fmt.Fprint(&b, v)
+		} else {
+			b.WriteString(tmpl[start : end+1])
+		}
+		tmpl = tmpl[end+1:]
+	}
+
+	b.WriteString(tmpl)
+	return b.String()
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
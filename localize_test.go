@@ -0,0 +1,108 @@
+package elk
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/studio-b12/elk/internal/assert"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	langs := ParseAcceptLanguage("fr-CH, fr;q=0.9, en;q=0.8, de;q=0.95, *;q=0.5, it;q=0")
+	assert.Equal(t, "fr-CH de fr en", strings.Join(langs, " "))
+
+	assert.Equal(t, 0, len(ParseAcceptLanguage("")))
+}
+
+func TestLocalizer(t *testing.T) {
+	const (
+		ErrNotFound = ErrorCode("not-found")
+		ErrQuota    = ErrorCode("quota-exceeded")
+		ErrUnknown  = ErrorCode("unknown")
+	)
+
+	l := NewLocalizer()
+	err := l.LoadFS(fstest.MapFS{
+		"locales/de.json": {Data: []byte(`{
+			"not-found": "Das Gerät {id} wurde nicht gefunden.",
+			"quota-exceeded": {"one": "Ein Gerät zu viel.", "other": "{count} Geräte zu viel."},
+			"required": "Das Feld ist erforderlich."
+		}`)},
+		"locales/en-US.json": {Data: []byte(`{
+			"quota-exceeded": {"zero": "No devices.", "one": "One device too many.", "other": "{count} devices too many."}
+		}`)},
+		"locales/README.md": {Data: []byte(`not a catalog`)},
+	}, "locales/*.json")
+	assert.Nil(t, err)
+
+	t.Run("placeholders", func(t *testing.T) {
+		e := NewError(ErrNotFound, "not found").With("id", 42)
+		assert.Equal(t, "Das Gerät 42 wurde nicht gefunden.", l.LocalizeError(e, "de-AT"))
+		assert.Equal(t, "not found", l.LocalizeError(e, "en-US", "fr"))
+
+		e = NewError(ErrNotFound, "not found")
+		assert.Equal(t, "Das Gerät {id} wurde nicht gefunden.", l.LocalizeError(e, "de"))
+	})
+
+	t.Run("plural", func(t *testing.T) {
+		for count, expected := range map[int]string{0: "No devices.", 1: "One device too many.", 3: "3 devices too many."} {
+			e := NewError(ErrQuota).With("count", count)
+			assert.Equal(t, expected, l.LocalizeError(e, "en-us"))
+		}
+
+		for count, expected := range map[int]string{0: "0 Geräte zu viel.", 1: "Ein Gerät zu viel.", 3: "3 Geräte zu viel."} {
+			e := NewError(ErrQuota).With("count", count)
+			assert.Equal(t, expected, l.LocalizeError(e, "de"))
+		}
+	})
+
+	t.Run("response-model", func(t *testing.T) {
+		model := l.ResponseModel(NewError(ErrUnknown, "some message"), 400, "de")
+		assert.Equal(t, "some message", model.Message)
+
+		v := NewValidation()
+		v.Add("name", "required", "name is required")
+		model = l.ResponseModel(Cast(v.Err()), 400, "de")
+		assert.Equal(t, "Das Feld ist erforderlich.", model.Details.([]ValidationEntry)[0].Message)
+		assert.Equal(t, "validation failed", model.Message)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		err := l.LoadJson("fr", strings.NewReader(`{"foo": 1}`))
+		assert.True(t, err != nil)
+	})
+}
+
+func TestJsonLocalized(t *testing.T) {
+	const ErrCode = ErrorCode("localize-test-code")
+
+	DefaultLocalizer.Register("de", Catalog{Messages: map[ErrorCode]Message{
+		ErrCode: {PluralOther: "Etwas ist schiefgelaufen."},
+	}})
+
+	var model ErrorResponseModel
+
+	data, err := JsonLocalized(NewError(ErrCode, "something went wrong"), 400, "en;q=0.5, de")
+	assert.Nil(t, err)
+	_ = json.Unmarshal(data, &model)
+	assert.Equal(t, "Etwas ist schiefgelaufen.", model.Message)
+
+	ctx := ContextWithLanguages(context.Background(), "en")
+	data, err = JsonContext(ctx, NewError(ErrCode, "something went wrong"), 400)
+	assert.Nil(t, err)
+	_ = json.Unmarshal(data, &model)
+	assert.Equal(t, "something went wrong", model.Message)
+
+	enc := DefaultEncoder
+	DefaultEncoder = &Encoder{Naming: CamelCase, Compact: true, Envelope: "error", Extra: map[string]any{"version": 2}}
+	defer func() { DefaultEncoder = enc }()
+
+	data, err = JsonLocalized(NewError(ErrCode, "something went wrong"), 400, "de")
+	assert.Nil(t, err)
+	assert.Equal(t,
+		`{"error":{"code":"localize-test-code","message":"Etwas ist schiefgelaufen.","status":400},"version":2}`,
+		string(data))
+}
@@ -0,0 +1,238 @@
+package elk
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// AggregatePolicy defines how the ErrorCode of a
+// MultiError is derived from its contained errors.
+type AggregatePolicy int
+
+const (
+	// AggregateCommon uses the code of the contained
+	// errors if all of them share the same code.
+	// Otherwise, the fallback code is used.
+	AggregateCommon AggregatePolicy = iota
+	// AggregateFirst uses the code of the first
+	// contained error.
+	AggregateFirst
+	// AggregateMostSevere uses the code of the first
+	// contained error with the highest Severity
+	// registered in the DefaultRegistry.
+	AggregateMostSevere
+	// AggregateFallback always uses the fallback
+	// code.
+	AggregateFallback
+)
+
+// MultiError contains multiple Errors, i.e. from a
+// batch operation failing in multiple distinct ways.
+//
+// The ErrorCode of the MultiError is derived from the
+// contained errors according to its AggregatePolicy.
+// By default, AggregateCommon is used with
+// CodeUnexpected as fallback code.
+type MultiError struct {
+	errs     []Error
+	policy   AggregatePolicy
+	fallback ErrorCode
+}
+
+var (
+	_ HasCode    = (*MultiError)(nil)
+	_ HasDetails = (*MultiError)(nil)
+)
+
+// NewMultiError creates a new MultiError containing
+// the given errors. See Add for more information.
+func NewMultiError(errs ...error) *MultiError {
+	var m MultiError
+	for _, err := range errs {
+		m.add(1, err)
+	}
+	return &m
+}
+
+// SetPolicy sets the policy used to derive the code
+// of the MultiError and the fallback code used by
+// the policy. The MultiError is returned to allow
+// chaining.
+func (t *MultiError) SetPolicy(policy AggregatePolicy, fallback ErrorCode) *MultiError {
+	t.policy = policy
+	t.fallback = fallback
+	return t
+}
+
+// Add adds the given error to the MultiError. Errors
+// which are not of type Error are casted using Cast.
+// The errors of a passed MultiError are added
+// individually. nil errors are ignored.
+func (t *MultiError) Add(err error) {
+	t.add(1, err)
+}
+
+// add implements Add, skipping the given number of callers
+// when capturing the call stack of casted errors.
+func (t *MultiError) add(skip int, err error) {
+	if err == nil {
+		return
+	}
+
+	if m, ok := err.(*MultiError); ok {
+		t.errs = append(t.errs, m.errs...)
+		return
+	}
+
+	e := cast(skip+1, err, CodeUnexpected)
+
+	t.errs = append(t.errs, e)
+}
+
+// Errors returns the contained errors.
+func (t *MultiError) Errors() []Error {
+	return t.errs
+}
+
+// Len returns the number of contained errors.
+func (t *MultiError) Len() int {
+	return len(t.errs)
+}
+
+// ErrorOrNil returns the MultiError if it contains
+// any errors. Otherwise, nil is returned.
+func (t *MultiError) ErrorOrNil() error {
+	if t == nil || len(t.errs) == 0 {
+		return nil
+	}
+	return t
+}
+
+// Code returns the ErrorCode derived from the
+// contained errors according to the policy.
+func (t *MultiError) Code() ErrorCode {
+	fallback := t.fallback
+	if fallback == "" {
+		fallback = CodeUnexpected
+	}
+
+	if len(t.errs) == 0 {
+		return fallback
+	}
+
+	switch t.policy {
+	case AggregateFirst:
+		return t.errs[0].code
+
+	case AggregateMostSevere:
+		code := t.errs[0].code
+		severity := SeverityUnspecified
+		for _, err := range t.errs {
+			info, _ := Lookup(err.code)
+			if info.Severity > severity {
+				code = err.code
+				severity = info.Severity
+			}
+		}
+		return code
+
+	case AggregateCommon:
+		code := t.errs[0].code
+		for _, err := range t.errs[1:] {
+			if err.code != code {
+				return fallback
+			}
+		}
+		return code
+	}
+
+	return fallback
+}
+
+// Details always returns nil, because a MultiError has
+// no details of its own. This prevents details of the
+// contained errors from being taken as the details of
+// an Error wrapping the MultiError.
+func (t *MultiError) Details() any {
+	return nil
+}
+
+// Unwrap returns the contained errors.
+func (t *MultiError) Unwrap() []error {
+	errs := make([]error, len(t.errs))
+	for i, err := range t.errs {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Error returns the %s formatted contained errors
+// separated by semicolons.
+func (t *MultiError) Error() string {
+	return fmt.Sprintf("%s", t)
+}
+
+// Format implements custom formatting rules used with the
+// formatting functionalities in the fmt package.
+//
+// %s, %q
+//
+// Prints the %s format of the contained errors separated
+// by semicolons.
+//
+// %v
+//
+// Without any flags, the MultiError is printed in the format
+// `<{errorCode}> {n} errors occurred: {errors}` where each
+// contained error is represented in its %v format.
+//
+// When passing the `+` or `#` flag, each contained error is
+// printed in a separate block using the same format verb,
+// flags and precision.
+func (t *MultiError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') || s.Flag('#') {
+			t.writeBlocks(s, fmt.FormatString(s, verb))
+		} else {
+			fmt.Fprintf(s, "<%s> %d errors occurred: ", t.Code(), len(t.errs))
+			t.writeList(s, "%v")
+		}
+	case 's', 'q':
+		t.writeList(s, "%s")
+	}
+}
+
+func (t *MultiError) writeList(s fmt.State, format string) {
+	for i, err := range t.errs {
+		if i > 0 {
+			fmt.Fprint(s, "; ")
+		}
+		fmt.Fprintf(s, format, err)
+	}
+}
+
+func (t *MultiError) writeBlocks(s fmt.State, format string) {
+	fmt.Fprintf(s, "<%s> %d errors occurred\n", t.Code(), len(t.errs))
+
+	for i, err := range t.errs {
+		fmt.Fprintf(s, "error [%d]:\n", i)
+
+		var b bytes.Buffer
+		fmt.Fprintf(&b, format, err)
+
+		for _, line := range strings.Split(strings.TrimRight(b.String(), "\n"), "\n") {
+			fmt.Fprintf(s, "  %s\n", line)
+		}
+	}
+}
+
+// ToResponseModel transforms the MultiError into an
+// ErrorResponseModel with the aggregated code. The
+// contained errors are represented in the Errors
+// field of the model.
+func (t *MultiError) ToResponseModel(statusCode int) ErrorResponseModel {
+	e := Wrap(t.Code(), t)
+	return e.ToResponseModel(statusCode)
+}
@@ -0,0 +1,101 @@
+package elk
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/studio-b12/elk/internal/assert"
+)
+
+var (
+	errMultiWarning = MustRegister("multi-test-warning", CodeInfo{Severity: SeverityWarning})
+	errMultiError   = MustRegister("multi-test-error", CodeInfo{Severity: SeverityError})
+)
+
+func TestMultiError(t *testing.T) {
+	const (
+		ErrCode      = ErrorCode("some-error-code")
+		ErrOtherCode = ErrorCode("some-other-error-code")
+		ErrFallback  = ErrorCode("fallback-code")
+	)
+
+	t.Run("empty", func(t *testing.T) {
+		m := NewMultiError(nil, nil)
+		assert.Equal(t, 0, m.Len())
+		assert.Nil(t, m.ErrorOrNil())
+		assert.Equal(t, CodeUnexpected, m.Code())
+	})
+
+	t.Run("add", func(t *testing.T) {
+		m := NewMultiError(NewError(ErrCode), errors.New("some error"))
+		m.Add(NewMultiError(NewError(ErrOtherCode)))
+
+		assert.Equal(t, 3, m.Len())
+		assert.Equal(t, ErrCode, m.Errors()[0].Code())
+		assert.Equal(t, CodeUnexpected, m.Errors()[1].Code())
+		assert.Equal(t, ErrOtherCode, m.Errors()[2].Code())
+		assert.True(t, errors.Is(m, ErrOtherCode.Sentinel()))
+	})
+
+	t.Run("policy", func(t *testing.T) {
+		m := NewMultiError(NewError(ErrCode), NewError(ErrCode))
+		assert.Equal(t, ErrCode, m.Code())
+
+		m.Add(NewError(ErrOtherCode))
+		assert.Equal(t, CodeUnexpected, m.Code())
+		assert.Equal(t, ErrFallback, m.SetPolicy(AggregateCommon, ErrFallback).Code())
+		assert.Equal(t, ErrCode, m.SetPolicy(AggregateFirst, ErrFallback).Code())
+		assert.Equal(t, ErrFallback, m.SetPolicy(AggregateFallback, ErrFallback).Code())
+
+		m = NewMultiError(NewError(ErrCode), NewError(errMultiWarning),
+			NewError(errMultiError), NewError(errMultiWarning))
+		assert.Equal(t, errMultiError, m.SetPolicy(AggregateMostSevere, "").Code())
+	})
+
+	t.Run("cast", func(t *testing.T) {
+		m := NewMultiError(NewError(ErrCode), NewError(ErrCode))
+		e := Cast(m)
+		assert.Equal(t, ErrCode, e.Code())
+		assert.True(t, errors.Unwrap(e) == error(m))
+	})
+
+	t.Run("format", func(t *testing.T) {
+		m := NewMultiError(
+			NewError(ErrCode, "some message"),
+			Wrap(ErrOtherCode, errors.New("some error")))
+
+		assert.Equal(t, "some message; some error", m.Error())
+		assert.Equal(t,
+			"<unexpected-error> 2 errors occurred: "+
+				"<some-error-code> some message (some-error-code); "+
+				"<some-other-error-code> (some error)",
+			fmt.Sprintf("%v", m))
+
+		s := fmt.Sprintf("%+.1v", m)
+		assert.True(t, strings.HasPrefix(s, "<unexpected-error> 2 errors occurred\nerror [0]:\n  <some-error-code> some message\n  stack:\n"))
+		assert.True(t, strings.Contains(s, "error [1]:\n  <some-other-error-code>\n"))
+
+		s = fmt.Sprintf("%#v", m)
+		assert.True(t, strings.Contains(s, "error [1]:\n  <some-other-error-code>\n  originated:\n"))
+	})
+
+	t.Run("response-model", func(t *testing.T) {
+		m := NewMultiError(
+			NewErrorWithDetails(ErrCode, "foo", "some message"),
+			NewError(ErrOtherCode))
+
+		model := Cast(m).ToResponseModel(400)
+		assert.Equal(t, CodeUnexpected, model.Code)
+		assert.Equal(t, nil, model.Details)
+		assert.Equal(t, 2, len(model.Errors))
+		assert.Equal(t, ErrCode, model.Errors[0].Code)
+		assert.Equal(t, "some message", model.Errors[0].Message)
+		assert.Equal[any](t, "foo", model.Errors[0].Details)
+		assert.Equal(t, ErrOtherCode, model.Errors[1].Code)
+
+		model = m.ToResponseModel(400)
+		assert.Equal(t, 2, len(model.Errors))
+	})
+}
@@ -0,0 +1,292 @@
+package elk
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// ProblemContentType is the media type of problem
+// details documents as specified in RFC 9457.
+const ProblemContentType = "application/problem+json"
+
+// ProblemDetails is a problem details document as
+// specified in RFC 9457.
+//
+// https://www.rfc-editor.org/rfc/rfc9457
+//
+// ProblemDetails implements error so that it can be
+// used as inner error of decoded Errors.
+type ProblemDetails struct {
+	Type     string
+	Title    string
+	Status   int
+	Detail   string
+	Instance string
+
+	// Extensions contains additional members of
+	// the problem details document.
+	Extensions map[string]any
+}
+
+var problemMembers = []string{"type", "title", "status", "detail", "instance"}
+
+func (t *ProblemDetails) Error() string {
+	if t.Detail != "" {
+		return t.Detail
+	}
+	if t.Title != "" {
+		return t.Title
+	}
+	return t.Type
+}
+
+// MarshalJSON encodes the problem details document
+// with its extension members on the top level.
+// Extension members with the name of one of the
+// standard members are omitted.
+func (t ProblemDetails) MarshalJSON() ([]byte, error) {
+	m := make(map[string]any, len(t.Extensions)+5)
+
+	for k, v := range t.Extensions {
+		m[k] = v
+	}
+
+	for _, k := range problemMembers {
+		delete(m, k)
+	}
+
+	if t.Type != "" {
+		m["type"] = t.Type
+	}
+	if t.Title != "" {
+		m["title"] = t.Title
+	}
+	if t.Status != 0 {
+		m["status"] = t.Status
+	}
+	if t.Detail != "" {
+		m["detail"] = t.Detail
+	}
+	if t.Instance != "" {
+		m["instance"] = t.Instance
+	}
+
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON decodes a problem details document.
+// All non-standard members are collected in
+// Extensions.
+func (t *ProblemDetails) UnmarshalJSON(data []byte) error {
+	var m map[string]json.RawMessage
+	err := json.Unmarshal(data, &m)
+	if err != nil {
+		return err
+	}
+
+	var p ProblemDetails
+	targets := []any{&p.Type, &p.Title, &p.Status, &p.Detail, &p.Instance}
+	for i, k := range problemMembers {
+		v, ok := m[k]
+		if !ok {
+			continue
+		}
+		delete(m, k)
+
+		// As specified in RFC 9457, members with
+		// invalid types are ignored.
+		_ = json.Unmarshal(v, targets[i])
+	}
+
+	for k, v := range m {
+		if p.Extensions == nil {
+			p.Extensions = make(map[string]any, len(m))
+		}
+
+		var ext any
+		err = json.Unmarshal(v, &ext)
+		if err != nil {
+			return err
+		}
+		p.Extensions[k] = ext
+	}
+
+	*t = p
+	return nil
+}
+
+// ProblemEncoder transforms errors into problem
+// details documents and back.
+//
+// The zero value is ready to use.
+type ProblemEncoder struct {
+	// BaseURI is prepended to the ErrorCode to
+	// form the type URI of the problem.
+	BaseURI string
+
+	// Instance returns the instance URI for the
+	// given error. If not set or if it returns an
+	// empty string, no instance is set.
+	Instance func(err Error) string
+}
+
+// DefaultProblemEncoder is the ProblemEncoder used
+// by ProblemJson and DecodeProblem.
+var DefaultProblemEncoder = &ProblemEncoder{}
+
+// Problem transforms the given error into a problem
+// details document.
+//
+// The type is built from the BaseURI and the code
+// of the error. The title is the description of the
+// code registered in the DefaultRegistry or else the
+// status text of the status code (or the error code
+// if no status is given). The detail is the errors
+// message. If statusCode is 0, the status code
+// registered for the code is used.
+// The error code is also added as "code" extension
+// member.
+//
+// If the details of the error encode to a JSON
+// object, its members are added as extension
+// members. Otherwise, or if one of the members is
+// named like a standard member, "code" or
+// "details", the details are added as "details"
+// extension member, so no member is lost.
+func (t *ProblemEncoder) Problem(err error, statusCode int) (ProblemDetails, error) {
+	e := cast(noCapture, err, CodeUnexpected)
+	model := e.ToResponseModel(statusCode)
+
+	p := ProblemDetails{
+		Type:   t.BaseURI + string(model.Code),
+		Title:  http.StatusText(model.Status),
+		Status: model.Status,
+		Detail: model.Message,
+	}
+
+	if info, ok := Lookup(model.Code); ok && info.Description != "" {
+		p.Title = info.Description
+	}
+
+	if p.Title == "" {
+		p.Title = string(model.Code)
+	}
+
+	if t.Instance != nil {
+		p.Instance = t.Instance(e)
+	}
+
+	p.Extensions = make(map[string]any)
+
+	if model.Details != nil {
+		data, jErr := json.Marshal(model.Details)
+		if jErr != nil {
+			return ProblemDetails{}, jErr
+		}
+
+		var members map[string]any
+		if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) &&
+			json.Unmarshal(data, &members) == nil && !hasReservedMember(members) {
+			p.Extensions = members
+		} else {
+			p.Extensions["details"] = json.RawMessage(data)
+		}
+	}
+
+	p.Extensions["code"] = model.Code
+
+	return p, nil
+}
+
+// hasReservedMember reports whether members contains
+// a member which collides with the members set by
+// ProblemEncoder.Problem.
+func hasReservedMember(members map[string]any) bool {
+	for _, k := range problemMembers {
+		if _, ok := members[k]; ok {
+			return true
+		}
+	}
+
+	_, code := members["code"]
+	_, details := members["details"]
+
+	return code || details
+}
+
+// Json transforms the given error into a problem
+// details document and marshals it into a JSON
+// byte slice.
+func (t *ProblemEncoder) Json(err error, statusCode int) ([]byte, error) {
+	p, pErr := t.Problem(err, statusCode)
+	if pErr != nil {
+		return nil, pErr
+	}
+
+	return json.MarshalIndent(p, "", "  ")
+}
+
+// Decode unmarshals the given problem details
+// document and transforms it into an Error.
+//
+// The code of the Error is taken from the "code"
+// extension member. If not present, the type URI
+// stripped of the BaseURI is used. The message is
+// taken from the detail. All remaining extension
+// members are set as details of the Error.
+//
+// The decoded ProblemDetails is the inner error of
+// the returned Error.
+func (t *ProblemEncoder) Decode(data []byte) (Error, error) {
+	return t.decode(1, data)
+}
+
+// decode implements Decode, skipping the given number of
+// callers when capturing the call stack.
+func (t *ProblemEncoder) decode(skip int, data []byte) (Error, error) {
+	var p ProblemDetails
+	err := json.Unmarshal(data, &p)
+	if err != nil {
+		return Error{}, err
+	}
+
+	code := ErrorCode(strings.TrimPrefix(p.Type, t.BaseURI))
+	if c, ok := p.Extensions["code"].(string); ok {
+		code = ErrorCode(c)
+	}
+
+	var details any
+	if len(p.Extensions) > 0 {
+		members := make(map[string]any, len(p.Extensions))
+		for k, v := range p.Extensions {
+			if k != "code" {
+				members[k] = v
+			}
+		}
+
+		if d, ok := members["details"]; ok && len(members) == 1 {
+			details = d
+		} else if len(members) > 0 {
+			details = members
+		}
+	}
+
+	e := wrap(skip+1, code, &p, []string{p.Detail})
+	if details != nil {
+		e = e.WithDetails(details)
+	}
+
+	return e, nil
+}
+
+// ProblemJson is shorthand for DefaultProblemEncoder.Json.
+func ProblemJson(err error, statusCode int) ([]byte, error) {
+	return DefaultProblemEncoder.Json(err, statusCode)
+}
+
+// DecodeProblem is shorthand for DefaultProblemEncoder.Decode.
+func DecodeProblem(data []byte) (Error, error) {
+	return DefaultProblemEncoder.decode(1, data)
+}
@@ -0,0 +1,86 @@
+package elk
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/studio-b12/elk/internal/assert"
+)
+
+func TestProblem(t *testing.T) {
+	const ErrCode = ErrorCode("some-error-code")
+
+	t.Run("scalar-details", func(t *testing.T) {
+		err := NewErrorWithDetails(ErrCode, []int{1, 2}, "some message")
+
+		data, pErr := ProblemJson(err, 400)
+		assert.Nil(t, pErr)
+		assert.True(t, strings.Contains(string(data), `"details": [`))
+
+		decoded, dErr := DecodeProblem(data)
+		assert.Nil(t, dErr)
+		assert.Equal(t, ErrCode, decoded.Code())
+		assert.Equal(t, "some message", decoded.Message())
+		assert.Equal(t, 2, len(decoded.Details().([]any)))
+
+		p, ok := As[*ProblemDetails](decoded)
+		assert.True(t, ok)
+		assert.Equal(t, 400, p.Status)
+	})
+
+	t.Run("reserved-members", func(t *testing.T) {
+		for _, member := range []string{"type", "title", "status", "detail", "instance", "code", "details"} {
+			details := map[string]any{member: "foo", "bar": float64(1)}
+			err := NewErrorWithDetails(ErrCode, details)
+
+			p, pErr := DefaultProblemEncoder.Problem(err, 0)
+			assert.Nil(t, pErr)
+			assert.Equal(t, string(ErrCode), p.Title)
+
+			data, _ := json.Marshal(p)
+			var m map[string]any
+			_ = json.Unmarshal(data, &m)
+			assert.Equal[any](t, string(ErrCode), m["code"])
+			assert.Equal(t, nil, m["bar"])
+
+			decoded, dErr := DecodeProblem(data)
+			assert.Nil(t, dErr)
+			assert.Equal(t, ErrCode, decoded.Code())
+			assert.Equal[any](t, "foo", decoded.Details().(map[string]any)[member])
+			assert.Equal[any](t, float64(1), decoded.Details().(map[string]any)["bar"])
+		}
+	})
+
+	t.Run("object-details", func(t *testing.T) {
+		err := NewErrorWithDetails(ErrCode, map[string]any{"bar": 1})
+
+		data, pErr := ProblemJson(err, 0)
+		assert.Nil(t, pErr)
+
+		var m map[string]any
+		_ = json.Unmarshal(data, &m)
+		assert.Equal[any](t, float64(1), m["bar"])
+	})
+
+	t.Run("decode-without-code", func(t *testing.T) {
+		enc := ProblemEncoder{BaseURI: "https://example.com/problems/"}
+		decoded, err := enc.Decode([]byte(`{
+			"type": "https://example.com/problems/out-of-credit",
+			"title": "You do not have enough credit.",
+			"status": "invalid",
+			"balance": 30
+		}`))
+
+		assert.Nil(t, err)
+		assert.Equal(t, ErrorCode("out-of-credit"), decoded.Code())
+		assert.Equal[any](t, float64(30), decoded.Details().(map[string]any)["balance"])
+		assert.Equal(t, "You do not have enough credit.", errors.Unwrap(decoded).Error())
+	})
+
+	t.Run("decode-invalid", func(t *testing.T) {
+		_, err := DecodeProblem([]byte(`[]`))
+		assert.True(t, err != nil)
+	})
+}
@@ -0,0 +1,235 @@
+package elk
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"os"
+	"reflect"
+	"runtime/debug"
+	"slices"
+	"sync"
+
+	"github.com/studio-b12/elk/internal/buildid"
+)
+
+// AnchorFunction is the name of the function whose
+// address is recorded in each RawCallStack. Comparing
+// it to the address of the function in the binary
+// yields the relocation of the program counters of
+// position independent executables.
+const AnchorFunction = "github.com/studio-b12/elk.stackAnchor"
+
+// rawCallStackVersion is the version of the binary
+// encoding of RawCallStack.
+const rawCallStackVersion = 1
+
+// RawCallStack is the compact serializable form of a
+// CallStack. It only contains the captured program
+// counters and information about the build of the
+// binary, so the frames can be resolved offline by
+// using the binary, for example with the elk command
+// line tool.
+type RawCallStack struct {
+	// Module is the path of the main module.
+	Module string
+
+	// Version is the version of the main module.
+	Version string
+
+	// Revision is the VCS revision the binary
+	// has been built from, if available.
+	Revision string
+
+	// BuildID is the Go build ID of the binary, if
+	// available. In contrast to the other build
+	// information, it identifies the binary even
+	// when it has been built without VCS information.
+	BuildID string
+
+	// Anchor is the address of AnchorFunction in
+	// the process which captured the call stack.
+	Anchor uintptr
+
+	// Offset is the number of frames which are
+	// skipped when resolving the frames.
+	Offset int
+
+	// PCs are the captured program counters.
+	PCs []uintptr
+}
+
+// stackAnchor is never called. Its address is used
+// as relocation reference for RawCallStacks.
+func stackAnchor() {}
+
+var (
+	rawBuildOnce sync.Once
+	rawBuild     RawCallStack
+)
+
+// Raw returns the RawCallStack of the CallStack
+// without resolving any frames. CallStacks which
+// have not been captured in this process, like the
+// ones decoded via UnmarshalDiagnostic, contain no
+// program counters. The returned program counters
+// are a copy, so they can be modified freely.
+func (t *CallStack) Raw() RawCallStack {
+	rawBuildOnce.Do(func() {
+		rawBuild.Anchor = reflect.ValueOf(stackAnchor).Pointer()
+
+		if exe, err := os.Executable(); err == nil {
+			rawBuild.BuildID = buildid.ReadFile(exe)
+		}
+
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+
+		rawBuild.Module = info.Main.Path
+		rawBuild.Version = info.Main.Version
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" {
+				rawBuild.Revision = s.Value
+			}
+		}
+	})
+
+	raw := rawBuild
+	if t != nil {
+		raw.Offset = t.offset
+		raw.PCs = slices.Clone(t.ptrs)
+	}
+
+	return raw
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+// The program counters are encoded as variable-length
+// deltas.
+func (t RawCallStack) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, 32+len(t.Module)+len(t.Version)+len(t.Revision)+len(t.BuildID)+3*len(t.PCs))
+
+	b = append(b, rawCallStackVersion)
+	for _, s := range []string{t.Module, t.Version, t.Revision, t.BuildID} {
+		b = binary.AppendUvarint(b, uint64(len(s)))
+		b = append(b, s...)
+	}
+	b = binary.AppendUvarint(b, uint64(t.Anchor))
+	b = binary.AppendUvarint(b, uint64(t.Offset))
+	b = binary.AppendUvarint(b, uint64(len(t.PCs)))
+
+	prev := t.Anchor
+	for _, pc := range t.PCs {
+		b = binary.AppendVarint(b, int64(pc-prev))
+		prev = pc
+	}
+
+	return b, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (t *RawCallStack) UnmarshalBinary(data []byte) error {
+	errInvalid := errors.New("invalid raw call stack encoding")
+
+	if len(data) == 0 || data[0] != rawCallStackVersion {
+		return errInvalid
+	}
+	data = data[1:]
+
+	readUvarint := func() (uint64, bool) {
+		v, n := binary.Uvarint(data)
+		if n <= 0 {
+			return 0, false
+		}
+		data = data[n:]
+		return v, true
+	}
+
+	var raw RawCallStack
+
+	for _, s := range []*string{&raw.Module, &raw.Version, &raw.Revision, &raw.BuildID} {
+		l, ok := readUvarint()
+		if !ok || l > uint64(len(data)) {
+			return errInvalid
+		}
+		*s = string(data[:l])
+		data = data[l:]
+	}
+
+	anchor, ok1 := readUvarint()
+	offset, ok2 := readUvarint()
+	n, ok3 := readUvarint()
+	if !ok1 || !ok2 || !ok3 || n > uint64(len(data)) {
+		return errInvalid
+	}
+
+	raw.Anchor = uintptr(anchor)
+	raw.Offset = int(offset)
+	raw.PCs = make([]uintptr, n)
+
+	prev := raw.Anchor
+	for i := range raw.PCs {
+		delta, l := binary.Varint(data)
+		if l <= 0 {
+			return errInvalid
+		}
+		data = data[l:]
+		prev += uintptr(delta)
+		raw.PCs[i] = prev
+	}
+
+	*t = raw
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+// The binary encoding is represented as unpadded
+// URL-safe base64 string.
+func (t RawCallStack) MarshalText() ([]byte, error) {
+	data, err := t.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	text := make([]byte, base64.RawURLEncoding.EncodedLen(len(data)))
+	base64.RawURLEncoding.Encode(text, data)
+	return text, nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (t *RawCallStack) UnmarshalText(text []byte) error {
+	data := make([]byte, base64.RawURLEncoding.DecodedLen(len(text)))
+	n, err := base64.RawURLEncoding.Decode(data, text)
+	if err != nil {
+		return err
+	}
+
+	return t.UnmarshalBinary(data[:n])
+}
+
+// Symbolize resolves the program counters into a
+// CallStack using the given resolve function.
+//
+// anchor is the address of AnchorFunction in the
+// binary. The program counters passed to resolve are
+// relocated by the difference between anchor and the
+// recorded Anchor. If anchor is 0, the program counters
+// are passed unchanged.
+func (t RawCallStack) Symbolize(anchor uintptr, resolve func(pc uintptr) []CallFrame) *CallStack {
+	slide := uintptr(0)
+	if anchor != 0 {
+		slide = t.Anchor - anchor
+	}
+
+	frames := make([]CallFrame, 0, len(t.PCs))
+	for _, pc := range t.PCs {
+		frames = append(frames, resolve(pc-slide)...)
+	}
+
+	return &CallStack{
+		frames: frames,
+		offset: t.Offset,
+	}
+}
@@ -0,0 +1,85 @@
+package elk
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/studio-b12/elk/internal/assert"
+)
+
+func TestRawCallStack(t *testing.T) {
+	const ErrCode = ErrorCode("some-error-code")
+
+	stack := NewError(ErrCode).CallStack()
+	raw := stack.Raw()
+
+	assert.Equal(t, len(stack.ptrs), len(raw.PCs))
+	assert.Equal(t, "github.com/studio-b12/elk", raw.Module)
+	assert.True(t, raw.Anchor != 0)
+	if runtime.GOOS == "linux" {
+		assert.True(t, raw.BuildID != "")
+	}
+
+	t.Run("encoding", func(t *testing.T) {
+		text, err := raw.MarshalText()
+		assert.Nil(t, err)
+
+		var decoded RawCallStack
+		err = decoded.UnmarshalText(text)
+		assert.Nil(t, err)
+
+		assert.Equal(t, raw.Module, decoded.Module)
+		assert.Equal(t, raw.Version, decoded.Version)
+		assert.Equal(t, raw.Revision, decoded.Revision)
+		assert.Equal(t, raw.BuildID, decoded.BuildID)
+		assert.Equal(t, raw.Anchor, decoded.Anchor)
+		assert.Equal(t, raw.Offset, decoded.Offset)
+		assert.Equal(t, len(raw.PCs), len(decoded.PCs))
+		for i := range raw.PCs {
+			assert.Equal(t, raw.PCs[i], decoded.PCs[i])
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		var decoded RawCallStack
+		assert.True(t, decoded.UnmarshalText([]byte("%")) != nil)
+		assert.True(t, decoded.UnmarshalBinary(nil) != nil)
+		assert.True(t, decoded.UnmarshalBinary([]byte{rawCallStackVersion, 10}) != nil)
+
+		data, _ := raw.MarshalBinary()
+		assert.True(t, decoded.UnmarshalBinary(data[:len(data)-1]) != nil)
+	})
+
+	t.Run("symbolize", func(t *testing.T) {
+		resolve := func(pc uintptr) []CallFrame {
+			frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+			return []CallFrame{CallFrame(frame)}
+		}
+
+		shifted := raw
+		shifted.Anchor += 0x1000
+		shifted.PCs = make([]uintptr, len(raw.PCs))
+		for i, pc := range raw.PCs {
+			shifted.PCs[i] = pc + 0x1000
+		}
+
+		symbolized := shifted.Symbolize(raw.Anchor, resolve)
+		assert.Equal(t, stack.String(), symbolized.String())
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		var stack *CallStack
+		assert.Equal(t, 0, len(stack.Raw().PCs))
+		assert.Equal(t, raw.Anchor, stack.Raw().Anchor)
+	})
+
+	t.Run("copy", func(t *testing.T) {
+		stack := NewError(ErrCode).CallStack()
+		pc := stack.ptrs[0]
+
+		raw := stack.Raw()
+		raw.PCs[0] = 0
+		assert.Equal(t, pc, stack.ptrs[0])
+		assert.Equal(t, pc, stack.Raw().PCs[0])
+	})
+}
@@ -0,0 +1,120 @@
+package elk
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PanicError is the inner error of Errors created
+// from recovered panics. It contains the value
+// passed to panic.
+type PanicError struct {
+	Value any
+}
+
+func (t *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", t.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (t *PanicError) Unwrap() error {
+	err, _ := t.Value.(error)
+	return err
+}
+
+// Recover recovers a panic and sets err to an Error
+// created from the recovered value via RecoverValue.
+// It must be called directly via defer.
+//
+//	func doWork() (err error) {
+//		defer elk.Recover(&err)
+//		// ...
+//	}
+//
+// If no panic occurred, err is left unchanged.
+func Recover(err *error, code ...ErrorCode) {
+	v := recover()
+	if v == nil {
+		return
+	}
+
+	*err = recoverValue(v, code)
+}
+
+// RecoverValue creates an Error from the given value
+// recovered from a panic. The inner error of the Error
+// is a *PanicError containing the value. By default,
+// the code of the Error is CodeUnexpected. Another
+// code can be passed optionally.
+//
+// When called during panicking, the CallStack of the
+// Error starts at the location where the panic has
+// occurred instead of the location of the recovery.
+func RecoverValue(v any, code ...ErrorCode) Error {
+	return recoverValue(v, code)
+}
+
+func recoverValue(v any, code []ErrorCode) Error {
+	c := CodeUnexpected
+	if len(code) > 0 {
+		c = code[0]
+	}
+
+	var d Error
+
+	d.code = c
+	d.Inner = &PanicError{Value: v}
+
+	policy := capturePolicy(c)
+	if policy.Mode == CaptureDisabled {
+		return d
+	}
+
+	// The full call stack is required to find the location
+	// of the panic, even if only the caller is kept.
+	d.callStack = newCallStack(2, policy.Depth+2)
+
+	if offset, ok := panicOffset(d.callStack.Frames()); ok {
+		d.callStack.offset += offset
+	}
+
+	if policy.Mode == CaptureCaller {
+		if frames := d.callStack.Frames(); len(frames) > 0 {
+			d.callStack = &CallStack{frames: frames[:1]}
+		}
+	}
+
+	return d
+}
+
+// panicOffset returns the offset of the frame in which
+// the panic has occurred, if the given frames have been
+// recorded during panicking.
+func panicOffset(frames []CallFrame) (int, bool) {
+	for i, frame := range frames {
+		if frame.Function != "runtime.gopanic" {
+			continue
+		}
+
+		// Runtime errors like nil pointer dereferences
+		// or out of range indices are raised via
+		// intermediate runtime functions, which are
+		// skipped as well.
+		offset := i + 1
+		for offset < len(frames) && isPanicFrame(frames[offset].Function) {
+			offset++
+		}
+
+		return offset, true
+	}
+
+	return 0, false
+}
+
+func isPanicFrame(function string) bool {
+	name, ok := strings.CutPrefix(function, "runtime.")
+	return ok &&
+		(strings.HasPrefix(name, "panic") ||
+			strings.HasPrefix(name, "goPanic") ||
+			name == "sigpanic")
+}
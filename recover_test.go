@@ -0,0 +1,85 @@
+package elk
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/studio-b12/elk/internal/assert"
+)
+
+func panicWithValue(v any) {
+	panic(v)
+}
+
+func panicWithNilPointer() {
+	var m *struct{ v int }
+	_ = m.v
+}
+
+func recoverPanic(f func()) (err error) {
+	defer Recover(&err)
+	f()
+	return nil
+}
+
+func TestRecover(t *testing.T) {
+	t.Run("no-panic", func(t *testing.T) {
+		err := recoverPanic(func() {})
+		assert.Nil(t, err)
+	})
+
+	t.Run("value", func(t *testing.T) {
+		err := recoverPanic(func() { panicWithValue("oh no") })
+
+		e, ok := err.(Error)
+		assert.True(t, ok)
+		assert.Equal(t, CodeUnexpected, e.Code())
+		assert.Equal(t, "panic: oh no", e.Inner.Error())
+
+		frame, _ := e.CallStack().First()
+		assert.True(t, strings.HasPrefix(frame, "github.com/studio-b12/elk.panicWithValue "))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		panicErr := errors.New("some error")
+		err := recoverPanic(func() { panicWithValue(panicErr) })
+
+		assert.True(t, errors.Is(err, panicErr))
+		pErr, ok := As[*PanicError](err)
+		assert.True(t, ok)
+		assert.Equal[any](t, panicErr, pErr.Value)
+	})
+
+	t.Run("runtime-error", func(t *testing.T) {
+		err := recoverPanic(panicWithNilPointer)
+
+		frame, _ := Cast(err).CallStack().First()
+		assert.True(t, strings.HasPrefix(frame, "github.com/studio-b12/elk.panicWithNilPointer "))
+	})
+
+	t.Run("recover-value", func(t *testing.T) {
+		const ErrCode = ErrorCode("some-error-code")
+
+		var err Error
+		func() {
+			defer func() {
+				if v := recover(); v != nil {
+					err = RecoverValue(v, ErrCode)
+				}
+			}()
+			panicWithValue(123)
+		}()
+
+		assert.Equal(t, ErrCode, err.Code())
+		frame, _ := err.CallStack().First()
+		assert.True(t, strings.HasPrefix(frame, "github.com/studio-b12/elk.panicWithValue "))
+	})
+
+	t.Run("outside-panic", func(t *testing.T) {
+		err := RecoverValue("foo")
+
+		frame, _ := err.CallStack().First()
+		assert.True(t, strings.HasPrefix(frame, "github.com/studio-b12/elk.TestRecover.func6 "))
+	})
+}
@@ -0,0 +1,192 @@
+package elk
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Severity classifies how severe errors with a
+// given ErrorCode are.
+type Severity int
+
+const (
+	SeverityUnspecified Severity = iota
+	SeverityInfo
+	SeverityWarning
+	SeverityError
+	SeverityCritical
+)
+
+func (t Severity) String() string {
+	switch t {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	case SeverityCritical:
+		return "critical"
+	default:
+		return "unspecified"
+	}
+}
+
+// CodeInfo contains metadata of an ErrorCode.
+type CodeInfo struct {
+	// Status is the default HTTP status code
+	// for errors with the code.
+	Status int
+
+	// Description is a human readable description
+	// of the error code.
+	Description string
+
+	// Severity classifies how severe errors with
+	// the code are.
+	Severity Severity
+
+	// Retryable defines whether operations failing
+	// with the code can be retried.
+	Retryable bool
+
+	// Internal defines that the code must not be
+	// exposed to clients. ErrorResponseModels of
+	// errors with internal codes contain the code
+	// CodeUnexpected without message and details
+	// instead. Messages and details of errors wrapped
+	// by errors with internal codes are not exposed
+	// either.
+	Internal bool
+
+	// Capture defines how call stacks are captured
+	// for errors with the code. When its mode is
+	// CaptureDefault, the global CapturePolicy set
+	// via SetCapturePolicy is used.
+	Capture CapturePolicy
+}
+
+// Registry contains ErrorCodes with their
+// corresponding metadata.
+//
+// A Registry is safe for concurrent use.
+type Registry struct {
+	mtx   sync.RWMutex
+	codes map[ErrorCode]CodeInfo
+
+	// hasCapture is set when any code with a
+	// capture policy has been registered, so that
+	// the lookup can be skipped when capturing.
+	hasCapture atomic.Bool
+}
+
+// DefaultRegistry is the Registry consulted by
+// ToResponseModel, Json and other helpers.
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry returns a new empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		codes: make(map[ErrorCode]CodeInfo),
+	}
+}
+
+// Register adds the given code with its metadata
+// to the registry. If the code has already been
+// registered, an error with the code
+// CodeAlreadyRegistered is returned.
+func (t *Registry) Register(code ErrorCode, info CodeInfo) error {
+	t.mtx.Lock()
+	_, exists := t.codes[code]
+	if !exists {
+		t.codes[code] = info
+		if info.Capture.Mode != CaptureDefault {
+			t.hasCapture.Store(true)
+		}
+	}
+	t.mtx.Unlock()
+
+	// The error is created after releasing the lock because
+	// creating it looks up its capture policy in the
+	// DefaultRegistry.
+	if exists {
+		return NewErrorf(CodeAlreadyRegistered,
+			"error code %q has already been registered", code)
+	}
+
+	return nil
+}
+
+// MustRegister is an alias for Register but panics
+// when the code has already been registered. The
+// passed code is returned so that it can be used
+// directly in variable declarations.
+func (t *Registry) MustRegister(code ErrorCode, info CodeInfo) ErrorCode {
+	err := t.Register(code, info)
+	if err != nil {
+		panic(err)
+	}
+	return code
+}
+
+// Lookup returns the metadata of the given code,
+// if registered.
+func (t *Registry) Lookup(code ErrorCode) (info CodeInfo, ok bool) {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
+	info, ok = t.codes[code]
+	return info, ok
+}
+
+// Register is shorthand for DefaultRegistry.Register.
+func Register(code ErrorCode, info CodeInfo) error {
+	return DefaultRegistry.Register(code, info)
+}
+
+// MustRegister is shorthand for DefaultRegistry.MustRegister.
+//
+//	var ErrDeviceNotFound = elk.MustRegister("device-not-found", elk.CodeInfo{
+//		Status:      http.StatusNotFound,
+//		Description: "The device could not be found.",
+//	})
+func MustRegister(code ErrorCode, info CodeInfo) ErrorCode {
+	return DefaultRegistry.MustRegister(code, info)
+}
+
+// Lookup is shorthand for DefaultRegistry.Lookup.
+func Lookup(code ErrorCode) (CodeInfo, bool) {
+	return DefaultRegistry.Lookup(code)
+}
+
+// isInternal reports whether err has a code which
+// is registered as internal in the DefaultRegistry.
+func isInternal(err error) bool {
+	c, ok := err.(HasCode)
+	if !ok {
+		return false
+	}
+
+	info, ok := Lookup(c.Code())
+	return ok && info.Internal
+}
+
+// walkPublic behaves like Walk but skips errors with a
+// code registered as internal and all errors wrapped
+// by them.
+func walkPublic(err error, fn WalkFunc) {
+	skip := -1
+	Walk(err, func(err error, depth int, path []int) bool {
+		if skip >= 0 && depth > skip {
+			return true
+		}
+		skip = -1
+
+		if isInternal(err) {
+			skip = depth
+			return true
+		}
+
+		return fn(err, depth, path)
+	})
+}
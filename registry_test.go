@@ -0,0 +1,100 @@
+package elk
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/studio-b12/elk/internal/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	const ErrCode = ErrorCode("some-error-code")
+
+	r := NewRegistry()
+
+	_, ok := r.Lookup(ErrCode)
+	assert.False(t, ok)
+
+	err := r.Register(ErrCode, CodeInfo{Status: 404, Retryable: true})
+	assert.Nil(t, err)
+
+	info, ok := r.Lookup(ErrCode)
+	assert.True(t, ok)
+	assert.Equal(t, 404, info.Status)
+	assert.True(t, info.Retryable)
+
+	err = r.Register(ErrCode, CodeInfo{Status: 404})
+	assert.True(t, IsCode(err, CodeAlreadyRegistered))
+
+	defer func() {
+		assert.True(t, IsCode(recover().(error), CodeAlreadyRegistered))
+	}()
+	r.MustRegister(ErrCode, CodeInfo{})
+}
+
+var (
+	errRegistered = MustRegister("registry-test-registered", CodeInfo{
+		Status:      409,
+		Description: "Some conflict.",
+	})
+	errInternal = MustRegister("registry-test-internal", CodeInfo{
+		Status:   503,
+		Internal: true,
+	})
+)
+
+func TestRegistryResponseModel(t *testing.T) {
+	model := NewError(errRegistered, "some message").ToResponseModel(0)
+	assert.Equal(t, errRegistered, model.Code)
+	assert.Equal(t, 409, model.Status)
+	assert.Equal(t, "some message", model.Message)
+
+	model = NewError(errRegistered).ToResponseModel(400)
+	assert.Equal(t, 400, model.Status)
+
+	model = NewErrorWithDetails(errInternal, "foo", "some message").ToResponseModel(0)
+	assert.Equal(t, CodeUnexpected, model.Code)
+	assert.Equal(t, 503, model.Status)
+	assert.Equal(t, "", model.Message)
+	assert.Equal(t, nil, model.Details)
+
+	p, _ := DefaultProblemEncoder.Problem(NewError(errRegistered), 0)
+	assert.Equal(t, 409, p.Status)
+	assert.Equal(t, "Some conflict.", p.Title)
+}
+
+func TestRegistryResponseModelWrappedInternal(t *testing.T) {
+	inner := NewErrorWithDetails(errInternal, map[string]string{"dsn": "postgres://secret"}, "db password wrong")
+
+	t.Run("fmt", func(t *testing.T) {
+		data, err := Json(fmt.Errorf("ctx: %w", inner), 0)
+		assert.Nil(t, err)
+		assert.False(t, strings.Contains(string(data), "secret"))
+		assert.False(t, strings.Contains(string(data), "db password wrong"))
+
+		model := Cast(fmt.Errorf("ctx: %w", inner)).ToResponseModel(0)
+		assert.Equal(t, CodeUnexpected, model.Code)
+		assert.Equal(t, "", model.Message)
+		assert.Equal(t, nil, model.Details)
+	})
+
+	t.Run("wrapped", func(t *testing.T) {
+		model := Wrap(CodeUnexpected, inner).ToResponseModel(0)
+		assert.Equal(t, CodeUnexpected, model.Code)
+		assert.Equal(t, "", model.Message)
+		assert.Equal(t, nil, model.Details)
+
+		model = Wrap(CodeUnexpected, Wrap("registry-test-public", inner)).ToResponseModel(0)
+		assert.Equal(t, nil, model.Details)
+
+		model = Wrap(CodeUnexpected, errors.Join(inner, NewErrorWithDetails("registry-test-public", "foo"))).ToResponseModel(0)
+		assert.Equal[any](t, "foo", model.Details)
+	})
+}
+
+func TestRegistryDefaultDuplicate(t *testing.T) {
+	err := Register(errCaptureDisabled, CodeInfo{})
+	assert.True(t, IsCode(err, CodeAlreadyRegistered))
+}
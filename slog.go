@@ -0,0 +1,245 @@
+package elk
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// LogField is a set of fields which are emitted
+// in the log representation of an Error.
+type LogField uint
+
+const (
+	// LogCode emits the ErrorCode of the error.
+	LogCode LogField = 1 << iota
+	// LogMessage emits the public and internal message
+	// of the error, if set.
+	LogMessage
+	// LogInner emits the text of the inner error, if set.
+	LogInner
+	// LogOrigin emits the frame where the error has been created.
+	LogOrigin
+	// LogStack emits the call stack of the error.
+	LogStack
+	// LogAttrs emits the attributes of every Error in the chain.
+	LogAttrs
+	// LogRawStack emits the RawCallStack of the error in its
+	// text encoding without resolving any frames in-process.
+	LogRawStack
+
+	// LogDefault is the set of fields emitted by default.
+	LogDefault = LogCode | LogMessage | LogInner | LogOrigin | LogAttrs
+)
+
+// LogOptions control how an Error is represented
+// as slog.Value.
+type LogOptions struct {
+	// Fields defines which fields are emitted.
+	Fields LogField
+	// StackDepth is the maximum number of frames
+	// emitted when LogStack is set. If it is 0,
+	// all frames are emitted.
+	StackDepth int
+}
+
+var defaultLogOptions atomic.Pointer[LogOptions]
+
+func init() {
+	defaultLogOptions.Store(&LogOptions{
+		Fields:     LogDefault,
+		StackDepth: 10,
+	})
+}
+
+// SetLogOptions sets the LogOptions used by
+// Error.LogValue.
+func SetLogOptions(opts LogOptions) {
+	defaultLogOptions.Store(&opts)
+}
+
+// LogValue implements slog.LogValuer. The error is
+// represented as a group of the fields defined in
+// the options set via SetLogOptions.
+func (t Error) LogValue() slog.Value {
+	return defaultLogOptions.Load().LogValue(t)
+}
+
+// LogValue returns the representation of the given
+// Error as slog.Value according to the options.
+func (t LogOptions) LogValue(err Error) slog.Value {
+	return slog.GroupValue(t.appendAttrs(make([]slog.Attr, 0, 6), err)...)
+}
+
+// ErrorValue returns the representation of the given
+// error as slog.Value. If the error is or wraps an Error,
+// the text of the error is emitted as "text" alongside the
+// fields of the Error as defined by the options. Otherwise,
+// only the text of the error is returned.
+func (t LogOptions) ErrorValue(err error) slog.Value {
+	if value, ok := t.errorValue(err); ok {
+		return value
+	}
+
+	return slog.StringValue(err.Error())
+}
+
+func (t LogOptions) errorValue(err error) (slog.Value, bool) {
+	elkErr, ok := Find[Error](err)
+	if !ok {
+		return slog.Value{}, false
+	}
+
+	attrs := make([]slog.Attr, 0, 7)
+	attrs = append(attrs, slog.String("text", err.Error()))
+
+	return slog.GroupValue(t.appendAttrs(attrs, elkErr)...), true
+}
+
+func (t LogOptions) appendAttrs(attrs []slog.Attr, err Error) []slog.Attr {
+	if t.Fields&LogCode != 0 {
+		attrs = append(attrs, slog.String("code", string(err.code)))
+	}
+
+	if t.Fields&LogMessage != 0 && err.message != "" {
+		attrs = append(attrs, slog.String("message", err.message))
+	}
+
+	if t.Fields&LogMessage != 0 && err.internal != "" {
+		attrs = append(attrs, slog.String("internal_message", err.internal))
+	}
+
+	if t.Fields&LogInner != 0 && err.Inner != nil {
+		attrs = append(attrs, slog.String("error", err.Inner.Error()))
+	}
+
+	if t.Fields&LogOrigin != 0 {
+		if frame, ok := err.CallStack().First(); ok {
+			attrs = append(attrs, slog.String("origin", frame))
+		}
+	}
+
+	if t.Fields&LogStack != 0 {
+		frames := err.CallStack().Filter()
+		if t.StackDepth > 0 && len(frames) > t.StackDepth {
+			frames = frames[:t.StackDepth]
+		}
+
+		stack := make([]string, 0, len(frames))
+		for _, frame := range frames {
+			stack = append(stack, frame.String())
+		}
+
+		attrs = append(attrs, slog.Any("stack", stack))
+	}
+
+	if t.Fields&LogRawStack != 0 && err.CallStack() != nil {
+		if raw, rErr := err.CallStack().Raw().MarshalText(); rErr == nil {
+			attrs = append(attrs, slog.String("raw_stack", string(raw)))
+		}
+	}
+
+	if t.Fields&LogAttrs != 0 {
+		if errAttrs := err.Attrs(); len(errAttrs) > 0 {
+			group := make([]any, 0, len(errAttrs))
+			for _, attr := range errAttrs {
+				group = append(group, slog.Any(attr.Key, attr.Value))
+			}
+			attrs = append(attrs, slog.Group("attrs", group...))
+		}
+	}
+
+	return attrs
+}
+
+// SlogHandler wraps a slog.Handler and expands every
+// attribute with an error value which is or wraps an
+// Error into a group as defined by the LogOptions. The
+// text of the logged error is kept in the "text" field
+// of the group, so messages of wrapping errors are not
+// lost.
+type SlogHandler struct {
+	handler slog.Handler
+	opts    LogOptions
+}
+
+var _ slog.Handler = (*SlogHandler)(nil)
+
+// NewSlogHandler returns a new SlogHandler wrapping the
+// given handler. If opts is nil, the options set via
+// SetLogOptions are used.
+func NewSlogHandler(handler slog.Handler, opts *LogOptions) *SlogHandler {
+	if opts == nil {
+		opts = defaultLogOptions.Load()
+	}
+
+	return &SlogHandler{
+		handler: handler,
+		opts:    *opts,
+	}
+}
+
+// Enabled reports whether the wrapped handler handles
+// records at the given level.
+func (t *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return t.handler.Enabled(ctx, level)
+}
+
+// Handle expands the error attributes of the record and
+// passes it to the wrapped handler.
+func (t *SlogHandler) Handle(ctx context.Context, record slog.Record) error {
+	expanded := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		expanded.AddAttrs(t.expand(attr))
+		return true
+	})
+
+	return t.handler.Handle(ctx, expanded)
+}
+
+// WithAttrs returns a new SlogHandler whose wrapped handler
+// has the given expanded attributes.
+func (t *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	expanded := make([]slog.Attr, 0, len(attrs))
+	for _, attr := range attrs {
+		expanded = append(expanded, t.expand(attr))
+	}
+
+	return &SlogHandler{
+		handler: t.handler.WithAttrs(expanded),
+		opts:    t.opts,
+	}
+}
+
+// WithGroup returns a new SlogHandler whose wrapped handler
+// has the given group.
+func (t *SlogHandler) WithGroup(name string) slog.Handler {
+	return &SlogHandler{
+		handler: t.handler.WithGroup(name),
+		opts:    t.opts,
+	}
+}
+
+func (t *SlogHandler) expand(attr slog.Attr) slog.Attr {
+	switch attr.Value.Kind() {
+	case slog.KindGroup:
+		group := attr.Value.Group()
+		expanded := make([]slog.Attr, 0, len(group))
+		for _, groupAttr := range group {
+			expanded = append(expanded, t.expand(groupAttr))
+		}
+		return slog.Attr{Key: attr.Key, Value: slog.GroupValue(expanded...)}
+
+	case slog.KindAny, slog.KindLogValuer:
+		err, ok := attr.Value.Any().(error)
+		if !ok {
+			break
+		}
+
+		if value, ok := t.opts.errorValue(err); ok {
+			return slog.Attr{Key: attr.Key, Value: value}
+		}
+	}
+
+	return attr
+}
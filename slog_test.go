@@ -0,0 +1,127 @@
+package elk
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/studio-b12/elk/internal/assert"
+)
+
+func logJson(t *testing.T, handler func(*bytes.Buffer) slog.Handler, args ...any) map[string]any {
+	t.Helper()
+
+	var buf bytes.Buffer
+	slog.New(handler(&buf)).Error("some message", args...)
+
+	var m map[string]any
+	err := json.Unmarshal(buf.Bytes(), &m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return m
+}
+
+func TestLogValue(t *testing.T) {
+	const ErrCode = ErrorCode("some-error-code")
+
+	jsonHandler := func(buf *bytes.Buffer) slog.Handler {
+		return slog.NewJSONHandler(buf, nil)
+	}
+
+	err := Wrap(ErrCode, errors.New("some error"), "some message").With("id", 42)
+	m := logJson(t, jsonHandler, "err", err)
+
+	group := m["err"].(map[string]any)
+	assert.Equal[any](t, string(ErrCode), group["code"])
+	assert.Equal[any](t, "some message", group["message"])
+	assert.Equal[any](t, "some error", group["error"])
+	assert.Equal[any](t, float64(42), group["attrs"].(map[string]any)["id"])
+	assert.Equal(t, nil, group["stack"])
+
+	origin, _ := err.CallStack().First()
+	assert.Equal[any](t, origin, group["origin"])
+}
+
+func TestSlogHandler(t *testing.T) {
+	const ErrCode = ErrorCode("some-error-code")
+
+	handler := func(opts *LogOptions) func(*bytes.Buffer) slog.Handler {
+		return func(buf *bytes.Buffer) slog.Handler {
+			return NewSlogHandler(slog.NewJSONHandler(buf, nil), opts)
+		}
+	}
+
+	t.Run("wrapped", func(t *testing.T) {
+		err := fmt.Errorf("wrapped: %w", NewError(ErrCode, "some message"))
+		m := logJson(t, handler(nil), "err", err)
+
+		group := m["err"].(map[string]any)
+		assert.Equal[any](t, "wrapped: <some-error-code> some message (some-error-code)", group["text"])
+		assert.Equal[any](t, string(ErrCode), group["code"])
+		assert.Equal[any](t, "some message", group["message"])
+	})
+
+	t.Run("non-elk", func(t *testing.T) {
+		m := logJson(t, handler(nil), "err", errors.New("some error"))
+		assert.Equal[any](t, "some error", m["err"])
+	})
+
+	t.Run("options", func(t *testing.T) {
+		opts := &LogOptions{Fields: LogCode | LogStack, StackDepth: 2}
+		err := NewError(ErrCode, "some message")
+		m := logJson(t, handler(opts), slog.Group("group", "err", err))
+
+		group := m["group"].(map[string]any)["err"].(map[string]any)
+		assert.Equal[any](t, "some message", group["text"])
+		assert.Equal[any](t, string(ErrCode), group["code"])
+		assert.Equal(t, nil, group["message"])
+		assert.Equal(t, 2, len(group["stack"].([]any)))
+	})
+
+	t.Run("with-attrs", func(t *testing.T) {
+		opts := &LogOptions{Fields: LogCode}
+		var buf bytes.Buffer
+		logger := slog.New(NewSlogHandler(slog.NewJSONHandler(&buf, nil), opts)).
+			With("err", fmt.Errorf("wrapped: %w", NewError(ErrCode)))
+		logger.Info("some message")
+
+		var m map[string]any
+		_ = json.Unmarshal(buf.Bytes(), &m)
+		assert.Equal[any](t, string(ErrCode), m["err"].(map[string]any)["code"])
+	})
+}
+
+func TestErrorValue(t *testing.T) {
+	const ErrCode = ErrorCode("some-error-code")
+
+	opts := LogOptions{Fields: LogCode}
+
+	value := opts.ErrorValue(errors.New("some error"))
+	assert.Equal(t, slog.KindString, value.Kind())
+	assert.Equal(t, "some error", value.String())
+
+	value = opts.ErrorValue(fmt.Errorf("loading user 42: %w", NewError(ErrCode, "some message")))
+	assert.Equal(t, slog.KindGroup, value.Kind())
+	assert.Equal(t, "[text=loading user 42: <some-error-code> some message (some-error-code) code=some-error-code]", value.String())
+}
+
+func TestLogRawStack(t *testing.T) {
+	const ErrCode = ErrorCode("some-error-code")
+
+	handler := func(buf *bytes.Buffer) slog.Handler {
+		return NewSlogHandler(slog.NewJSONHandler(buf, nil), &LogOptions{Fields: LogRawStack})
+	}
+
+	err := NewError(ErrCode)
+	m := logJson(t, handler, "err", err)
+
+	var raw RawCallStack
+	uErr := raw.UnmarshalText([]byte(m["err"].(map[string]any)["raw_stack"].(string)))
+	assert.Nil(t, uErr)
+	assert.Equal(t, err.CallStack().ptrs[0], raw.PCs[0])
+}
@@ -0,0 +1,322 @@
+package elk
+
+import (
+	"path"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// FrameFilter reports whether the given call frame
+// is kept in formatted call stacks.
+type FrameFilter func(frame CallFrame) bool
+
+// PathRewriter returns the file path which is shown
+// for the given call frame in formatted call stacks.
+type PathRewriter func(frame CallFrame) string
+
+// StackOptions define which frames of a CallStack are
+// shown in formatted outputs and how their file paths
+// are represented.
+type StackOptions struct {
+	// Filters are applied to each frame of the call
+	// stack. A frame is dropped when any of the
+	// filters returns false.
+	Filters []FrameFilter
+
+	// Rewriters are applied in order to the file path
+	// of each kept frame. Each rewriter receives the
+	// frame with the path returned by the previous one.
+	Rewriters []PathRewriter
+}
+
+var defaultStackOptions atomic.Pointer[StackOptions]
+
+func init() {
+	defaultStackOptions.Store(&StackOptions{})
+}
+
+// SetStackOptions sets the StackOptions used by all
+// formatters of call stacks when no options are passed
+// explicitly. By default, all frames are shown with
+// their original file paths.
+func SetStackOptions(opts StackOptions) {
+	defaultStackOptions.Store(&opts)
+}
+
+// Apply returns the frames which pass the filters with
+// their file paths rewritten by the rewriters. The passed
+// slice is not modified.
+func (t StackOptions) Apply(frames []CallFrame) []CallFrame {
+	if len(t.Filters) == 0 && len(t.Rewriters) == 0 {
+		return frames
+	}
+
+	res := make([]CallFrame, 0, len(frames))
+
+outer:
+	for _, frame := range frames {
+		for _, filter := range t.Filters {
+			if !filter(frame) {
+				continue outer
+			}
+		}
+
+		for _, rewrite := range t.Rewriters {
+			frame.File = rewrite(frame)
+		}
+
+		res = append(res, frame)
+	}
+
+	return res
+}
+
+// resolveStackOptions merges the given options or returns
+// the options set via SetStackOptions when none are given.
+func resolveStackOptions(opts []StackOptions) StackOptions {
+	switch len(opts) {
+	case 0:
+		return *defaultStackOptions.Load()
+	case 1:
+		return opts[0]
+	}
+
+	var res StackOptions
+	for _, o := range opts {
+		res.Filters = append(res.Filters, o.Filters...)
+		res.Rewriters = append(res.Rewriters, o.Rewriters...)
+	}
+
+	return res
+}
+
+// DropRuntime is a FrameFilter which drops all frames
+// of the runtime package like runtime.goexit.
+func DropRuntime(frame CallFrame) bool {
+	pkg := framePackage(frame)
+	return pkg != "runtime" && !strings.HasPrefix(pkg, "runtime/")
+}
+
+// DropStdlib is a FrameFilter which drops all frames
+// of packages of the standard library, including the
+// runtime package.
+func DropStdlib(frame CallFrame) bool {
+	if root := goroot(); root != "" {
+		return !strings.HasPrefix(frame.File, root+"/src/")
+	}
+
+	// Without a known GOROOT (i.e. when built with -trimpath),
+	// standard library packages are detected by the missing
+	// domain in the first element of their import path.
+	pkg := framePackage(frame)
+	if pkg == "" || pkg == "main" {
+		return true
+	}
+
+	first, _, _ := strings.Cut(pkg, "/")
+	return strings.Contains(first, ".")
+}
+
+// DropPackages returns a FrameFilter which drops all
+// frames of packages with one of the given import path
+// prefixes. A prefix matches whole path elements only.
+func DropPackages(prefixes ...string) FrameFilter {
+	return func(frame CallFrame) bool {
+		pkg := framePackage(frame)
+		for _, prefix := range prefixes {
+			if hasPathPrefix(pkg, prefix) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// KeepModule returns a FrameFilter which only keeps
+// frames of packages in the module with the given path.
+// When path is empty, the main module of the running
+// binary is used. Frames of the main package are kept
+// as well in this case.
+func KeepModule(path string) FrameFilter {
+	keepMain := false
+	if path == "" {
+		keepMain = true
+		if info, ok := debug.ReadBuildInfo(); ok {
+			path = info.Main.Path
+		}
+	}
+
+	return func(frame CallFrame) bool {
+		pkg := framePackage(frame)
+		if keepMain && pkg == "main" {
+			return true
+		}
+		return path != "" && hasPathPrefix(pkg, path)
+	}
+}
+
+// TrimModule is a PathRewriter which represents the file
+// paths of frames in the main module or any dependency
+// listed in the build info as the module path followed
+// by the path of the file relative to the module root,
+// as it would be produced by building with -trimpath
+// (e.g. "github.com/studio-b12/elk/error.go").
+//
+// Frames of the main package are resolved via the import
+// path of the main package from the build info. They are
+// kept unchanged when it is not known, e.g. when the binary
+// has been built from a list of files.
+func TrimModule(frame CallFrame) string {
+	return loadBuildModules().trim(frame)
+}
+
+// TrimGoroot is a PathRewriter which represents the
+// file paths of frames in the standard library relative
+// to the source directory of GOROOT
+// (e.g. "net/http/server.go").
+func TrimGoroot(frame CallFrame) string {
+	if root := goroot(); root != "" {
+		if rel, ok := strings.CutPrefix(frame.File, root+"/src/"); ok {
+			return rel
+		}
+	}
+	return frame.File
+}
+
+// TrimPrefix returns a PathRewriter which removes the
+// first matching of the given prefixes from file paths.
+func TrimPrefix(prefixes ...string) PathRewriter {
+	return func(frame CallFrame) string {
+		for _, prefix := range prefixes {
+			if rel, ok := strings.CutPrefix(frame.File, prefix); ok {
+				return rel
+			}
+		}
+		return frame.File
+	}
+}
+
+// framePackage returns the import path of the package
+// of the function of the given frame.
+func framePackage(frame CallFrame) string {
+	fn := frame.Function
+
+	lastSlash := strings.LastIndexByte(fn, '/')
+	if lastSlash < 0 {
+		lastSlash = 0
+	}
+
+	dot := strings.IndexByte(fn[lastSlash:], '.')
+	if dot < 0 {
+		return fn
+	}
+
+	return fn[:lastSlash+dot]
+}
+
+func hasPathPrefix(s, prefix string) bool {
+	return s == prefix || strings.HasPrefix(s, strings.TrimSuffix(prefix, "/")+"/")
+}
+
+var (
+	gorootOnce sync.Once
+	gorootDir  string
+)
+
+// goroot returns the GOROOT the binary has been built
+// with. It is derived from the file path of a runtime
+// function, so it is empty when built with -trimpath.
+func goroot() string {
+	gorootOnce.Do(func() {
+		var pcs [1]uintptr
+		runtime.Callers(0, pcs[:])
+		frame, _ := runtime.CallersFrames(pcs[:]).Next()
+		if i := strings.LastIndex(frame.File, "/src/runtime/"); i > 0 {
+			gorootDir = frame.File[:i]
+		}
+	})
+	return gorootDir
+}
+
+// buildModules describes the packages and modules
+// listed in the build info of the running binary.
+type buildModules struct {
+	// mainPkg is the import path of the main package.
+	mainPkg string
+
+	// modules contains the paths of the main module
+	// and of all dependencies.
+	modules []string
+}
+
+var (
+	buildModulesOnce sync.Once
+	buildModulesInfo buildModules
+)
+
+// loadBuildModules returns the buildModules read from
+// the build info of the running binary.
+func loadBuildModules() buildModules {
+	buildModulesOnce.Do(func() {
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		buildModulesInfo.mainPkg = info.Path
+		if info.Main.Path != "" {
+			buildModulesInfo.modules = append(buildModulesInfo.modules, info.Main.Path)
+		}
+		for _, dep := range info.Deps {
+			buildModulesInfo.modules = append(buildModulesInfo.modules, dep.Path)
+		}
+	})
+	return buildModulesInfo
+}
+
+// trim implements TrimModule for the given modules.
+func (t buildModules) trim(frame CallFrame) string {
+	pkg := framePackage(frame)
+	if pkg == "main" {
+		pkg = t.mainPkg
+	}
+
+	mod, ok := t.moduleOf(pkg)
+	if !ok {
+		return frame.File
+	}
+
+	dir, file := path.Split(frame.File)
+	dir = strings.TrimSuffix(dir, "/")
+
+	sub := strings.TrimPrefix(pkg, mod)
+	if !strings.HasSuffix(dir, sub) {
+		return frame.File
+	}
+
+	root := strings.TrimSuffix(dir, sub)
+	if root == "" || root == mod {
+		return frame.File
+	}
+
+	return mod + sub + "/" + file
+}
+
+// moduleOf returns the path of the module
+// containing the given package.
+func (t buildModules) moduleOf(pkg string) (string, bool) {
+	if pkg == "" {
+		return "", false
+	}
+
+	mod := ""
+	for _, m := range t.modules {
+		if len(m) > len(mod) && hasPathPrefix(pkg, m) {
+			mod = m
+		}
+	}
+
+	return mod, mod != ""
+}
@@ -0,0 +1,149 @@
+package elk
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/studio-b12/elk/internal/assert"
+)
+
+func TestFramePackage(t *testing.T) {
+	assert.Equal(t, "main", framePackage(CallFrame{Function: "main.main"}))
+	assert.Equal(t, "runtime", framePackage(CallFrame{Function: "runtime.goexit"}))
+	assert.Equal(t, "net/http", framePackage(CallFrame{Function: "net/http.(*conn).serve"}))
+	assert.Equal(t, "github.com/studio-b12/elk",
+		framePackage(CallFrame{Function: "github.com/studio-b12/elk.Wrap"}))
+	assert.Equal(t, "github.com/studio-b12/elk/elkhttp",
+		framePackage(CallFrame{Function: "github.com/studio-b12/elk/elkhttp.Handler.func1"}))
+}
+
+func TestFrameFilters(t *testing.T) {
+	root := goroot()
+
+	frames := []CallFrame{
+		{Function: "main.main", File: "/src/app/main.go"},
+		{Function: "github.com/studio-b12/elk.Wrap", File: "/src/elk/error.go"},
+		{Function: "github.com/studio-b12/elk/elkhttp.Handler.func1", File: "/src/elk/elkhttp/handler.go"},
+		{Function: "net/http.(*conn).serve", File: root + "/src/net/http/server.go"},
+		{Function: "runtime.goexit", File: root + "/src/runtime/asm_amd64.s"},
+	}
+
+	functions := func(frames []CallFrame) string {
+		var names []string
+		for _, frame := range frames {
+			names = append(names, frame.Function)
+		}
+		return strings.Join(names, ",")
+	}
+
+	t.Run("runtime", func(t *testing.T) {
+		res := StackOptions{Filters: []FrameFilter{DropRuntime}}.Apply(frames)
+		assert.Equal(t, functions(frames[:4]), functions(res))
+	})
+
+	t.Run("stdlib", func(t *testing.T) {
+		res := StackOptions{Filters: []FrameFilter{DropStdlib}}.Apply(frames)
+		assert.Equal(t, functions(frames[:3]), functions(res))
+	})
+
+	t.Run("packages", func(t *testing.T) {
+		res := StackOptions{Filters: []FrameFilter{DropPackages("github.com/studio-b12/elk/elkhttp", "net")}}.Apply(frames)
+		assert.Equal(t, "main.main,github.com/studio-b12/elk.Wrap,runtime.goexit", functions(res))
+	})
+
+	t.Run("module", func(t *testing.T) {
+		res := StackOptions{Filters: []FrameFilter{KeepModule("github.com/studio-b12/elk")}}.Apply(frames)
+		assert.Equal(t, functions(frames[1:3]), functions(res))
+	})
+
+	t.Run("combined", func(t *testing.T) {
+		res := StackOptions{Filters: []FrameFilter{DropRuntime, DropPackages("main")}}.Apply(frames)
+		assert.Equal(t, functions(frames[1:4]), functions(res))
+	})
+}
+
+func TestPathRewriters(t *testing.T) {
+	t.Run("module", func(t *testing.T) {
+		frame := CallFrame{
+			Function: "github.com/studio-b12/elk/elkhttp.Handler.func1",
+			File:     "/home/foo/src/elk/elkhttp/handler.go",
+		}
+		assert.Equal(t, "github.com/studio-b12/elk/elkhttp/handler.go", TrimModule(frame))
+
+		frame = CallFrame{Function: "main.main", File: "/home/bar/main.go"}
+		assert.Equal(t, frame.File, TrimModule(frame))
+	})
+
+	t.Run("main", func(t *testing.T) {
+		mods := buildModules{
+			mainPkg: "github.com/studio-b12/elk/cmd",
+			modules: []string{"github.com/studio-b12/elk"},
+		}
+
+		frame := CallFrame{Function: "main.main", File: "/home/foo/src/elk/cmd/main.go"}
+		assert.Equal(t, "github.com/studio-b12/elk/cmd/main.go", mods.trim(frame))
+
+		frame = CallFrame{Function: "main.main", File: "/home/bar/main.go"}
+		assert.Equal(t, frame.File, mods.trim(frame))
+
+		mods.mainPkg = "command-line-arguments"
+		frame = CallFrame{Function: "main.main", File: "/home/foo/src/elk/cmd/main.go"}
+		assert.Equal(t, frame.File, mods.trim(frame))
+	})
+
+	t.Run("main-only", func(t *testing.T) {
+		mods := buildModules{
+			mainPkg: "example.com/rv",
+			modules: []string{"example.com/rv"},
+		}
+
+		// The main package is rewritten without any
+		// other frame of the module seen before.
+		frame := CallFrame{Function: "main.main", File: "/home/foo/rv/main.go"}
+		assert.Equal(t, "example.com/rv/main.go", mods.trim(frame))
+		assert.Equal(t, "example.com/rv/main.go", mods.trim(frame))
+	})
+
+	t.Run("goroot", func(t *testing.T) {
+		if goroot() == "" {
+			t.Skip("GOROOT is unknown")
+		}
+
+		frame := CallFrame{Function: "net/http.(*conn).serve", File: goroot() + "/src/net/http/server.go"}
+		assert.Equal(t, "net/http/server.go", TrimGoroot(frame))
+
+		frame = CallFrame{Function: "main.main", File: "/home/bar/main.go"}
+		assert.Equal(t, frame.File, TrimGoroot(frame))
+	})
+
+	t.Run("prefix", func(t *testing.T) {
+		rewrite := TrimPrefix("/home/foo/", "/home/bar/")
+		assert.Equal(t, "main.go", rewrite(CallFrame{File: "/home/bar/main.go"}))
+		assert.Equal(t, "/home/baz/main.go", rewrite(CallFrame{File: "/home/baz/main.go"}))
+	})
+}
+
+func TestStackOptions(t *testing.T) {
+	stack := newCallStack(0, 32)
+
+	var all, filtered strings.Builder
+	stack.Write(&all, 0)
+	stack.Write(&filtered, 0, StackOptions{
+		Filters:   []FrameFilter{DropStdlib},
+		Rewriters: []PathRewriter{TrimModule},
+	})
+
+	assert.True(t, strings.Contains(all.String(), "runtime.goexit"))
+	assert.False(t, strings.Contains(filtered.String(), "runtime.goexit"))
+	assert.False(t, strings.Contains(filtered.String(), "testing.tRunner"))
+	assert.True(t, strings.Contains(filtered.String(),
+		"github.com/studio-b12/elk/stackfilter_test.go"))
+
+	SetStackOptions(StackOptions{Filters: []FrameFilter{DropRuntime}})
+	defer SetStackOptions(StackOptions{})
+
+	var global strings.Builder
+	stack.Write(&global, 0)
+	assert.False(t, strings.Contains(global.String(), "runtime.goexit"))
+	assert.True(t, strings.Contains(global.String(), "testing.tRunner"))
+}
@@ -1,32 +1,59 @@
 package elk
 
 import (
+	"bytes"
 	"encoding/json"
-	"errors"
 )
 
 // UnwrapFull takes an error and unwraps it until
 // it can not be unwrapped anymore. Then, the
 // last error is returned.
+//
+// Only errors wrapping a single error are unwrapped.
+// Use Leaves to get the innermost errors of joined
+// errors as well.
+//
+// When the chain contains a cycle or is deeper than
+// the depth set via SetMaxUnwrapDepth, the last error
+// before the truncation is returned.
 func UnwrapFull(err error) error {
-	for {
-		uErr := errors.Unwrap(err)
-		if uErr == nil {
-			return err
-		}
-		err = uErr
-	}
+	last := err
+	unwrapChain(err, func(err error) bool {
+		last = err
+		return true
+	})
+	return last
 }
 
-// As applies errors.As() on the given err
-// using the given type T as target for the
-// unwrapping.
+// As behaves like errors.As() using the given
+// type T as target for the unwrapping. It returns
+// the first error in the tree of err, in the order
+// of Walk, which is of type T or whose
+// `As(any) bool` method sets the target.
+//
+// In contrast to errors.As(), the traversal stops at
+// cycles and at the depth set via SetMaxUnwrapDepth.
 //
 // Refer to the documentation of errors.As()
 // for more details:
 // https://pkg.go.dev/errors#As
 func As[T error](err error) (t T, ok bool) {
-	ok = errors.As(err, &t)
+	Walk(err, func(err error, _ int, _ []int) bool {
+		if t, ok = err.(T); ok {
+			return false
+		}
+		if a, isAs := err.(interface{ As(any) bool }); isAs && a.As(&t) {
+			ok = true
+			return false
+		}
+		return true
+	})
+
+	if !ok {
+		var zero T
+		t = zero
+	}
+
 	return t, ok
 }
 
@@ -34,84 +61,201 @@ func As[T error](err error) (t T, ok bool) {
 // error is of the type of T.
 //
 // If not and the error can be unwrapped,
-// the unwrapped error will be checked
-// until it either matches the type T or
-// can not be further unwrapped.
+// the unwrapped errors will be checked
+// until either one matches the type T or
+// they can not be further unwrapped. This
+// includes errors created with `errors.Join`
+// and other errors implementing
+// `Unwrap() []error`.
 func IsOfType[T error](err error) bool {
-	_, ok := err.(T)
-	if ok {
-		return true
-	}
-
-	err = errors.Unwrap(err)
-	if err != nil {
-		return IsOfType[T](err)
-	}
-
-	return false
+	_, ok := Find[T](err)
+	return ok
 }
 
 // IsCode is shorthand for `elk.Cast(err).Code() == errorCode`.
+//
+// Only the code of the outermost error is checked. Use
+// ContainsCode to check all errors in the chain.
 func IsCode(err error, code ErrorCode) bool {
 	return Cast(err).Code() == code
 }
 
+// ContainsCode returns true when the given error or any
+// error wrapped by it implements HasCode and has the
+// given code.
+//
+// In contrast to IsCode, this unwraps errors created with
+// `errors.Join` and other errors implementing
+// `Unwrap() []error` as well.
+func ContainsCode(err error, code ErrorCode) bool {
+	found := false
+	Walk(err, func(err error, _ int, _ []int) bool {
+		c, ok := err.(HasCode)
+		found = ok && c.Code() == code
+		return !found
+	})
+	return found
+}
+
 // ErrorResponseModel is used to encode an Error into an API response.
 type ErrorResponseModel struct {
 	Code    ErrorCode // The error code
 	Message string    `json:",omitempty"` // An optional short message to further specify the error
 	Status  int       `json:",omitempty"` // An optional platform- or protocol-specific status code; i.e. HTTP status code
 	Details any       `json:",omitempty"` // Optional additional detailed context for the error
+
+	Errors []ErrorResponseModel `json:",omitempty"` // The contained errors, if the error is a MultiError
+	Debug  *DebugInfo           `json:",omitempty"` // Debug information, only present if debug mode is enabled
+}
+
+// DebugInfo contains information about the internals of
+// an error which is only added to an ErrorResponseModel
+// in debug mode.
+type DebugInfo struct {
+	Chain []string // The %v formatted errors in the chain of the error
+	Stack []string // The call stack of the innermost error which has one
 }
 
-// ToResponseModel transforms the
+// ToResponseModel transforms the error into an ErrorResponseModel.
+//
+// If statusCode is 0 and the code of the error is registered in the
+// DefaultRegistry, the registered status code is used. If the code is
+// registered as internal, the model contains the code CodeUnexpected
+// without message and details instead. Details of wrapped errors with
+// a code registered as internal are never added to the model.
 func (t Error) ToResponseModel(statusCode int) (model ErrorResponseModel) {
+	info, registered := Lookup(t.Code())
+
+	if statusCode == 0 && registered {
+		statusCode = info.Status
+	}
+
 	model.Status = statusCode
+
+	if registered && info.Internal {
+		model.Code = CodeUnexpected
+		return model
+	}
+
 	model.Code = t.Code()
 
-	if mErr, ok := As[HasMessage](t); ok {
-		model.Message = mErr.Message()
+	model.Message = t.message
+	model.Details = findDetails(t, walkPublic)
+
+	walkPublic(t, func(err error, _ int, _ []int) bool {
+		mErr, ok := err.(*MultiError)
+		if !ok {
+			return true
+		}
+
+		model.Errors = make([]ErrorResponseModel, 0, mErr.Len())
+		for _, err := range mErr.errs {
+			model.Errors = append(model.Errors, err.ToResponseModel(0))
+		}
+		return false
+	})
+
+	return model
+}
+
+// Error returns a text representation of the model.
+//
+// ErrorResponseModel implements error so that it can
+// be used as inner error of decoded Errors.
+func (t *ErrorResponseModel) Error() string {
+	if t.Message != "" {
+		return t.Message
 	}
+	return string(t.Code)
+}
+
+// FromResponseModel transforms the given ErrorResponseModel
+// back into an Error with the code, message and details of
+// the model. The model is the inner error of the returned
+// Error, so the status code is accessible via
+// `elk.As[*elk.ErrorResponseModel]`.
+func FromResponseModel(model ErrorResponseModel) Error {
+	return fromResponseModel(1, model)
+}
 
-	if dErr, ok := As[HasDetails](t); ok {
-		model.Details = dErr.Details()
+// fromResponseModel implements FromResponseModel, skipping
+// the given number of callers when capturing the call stack.
+func fromResponseModel(skip int, model ErrorResponseModel) Error {
+	e := wrap(skip+1, model.Code, &model, []string{model.Message})
+	if model.Details != nil {
+		e = e.WithDetails(model.Details)
 	}
+	return e
+}
 
-	return model
+// DecodeJson unmarshals the JSON representation of an
+// ErrorResponseModel as produced by Json and transforms
+// it into an Error. The details are kept as raw JSON in
+// form of a json.RawMessage.
+//
+// The Naming and Envelope of the DefaultEncoder are
+// respected. Use Encoder.Decode to decode the output
+// of other Encoders.
+//
+// If the data does not contain a JSON object with an
+// error code, an error is returned.
+func DecodeJson(data []byte) (Error, error) {
+	return DefaultEncoder.decode(1, data)
+}
+
+// decodeJson decodes the JSON representation of an
+// ErrorResponseModel with the field names as keys,
+// skipping the given number of callers when capturing
+// the call stack.
+func decodeJson(skip int, data []byte) (Error, error) {
+	var raw struct {
+		ErrorResponseModel
+		Details json.RawMessage
+	}
+
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return Error{}, err
+	}
+
+	if raw.Code == "" {
+		return Error{}, NewError(CodeUnexpected, "data does not contain an error code")
+	}
+
+	model := raw.ErrorResponseModel
+	if len(raw.Details) > 0 && string(raw.Details) != "null" {
+		var details bytes.Buffer
+		err = json.Compact(&details, raw.Details)
+		if err != nil {
+			return Error{}, err
+		}
+		model.Details = json.RawMessage(details.Bytes())
+	}
+
+	return fromResponseModel(skip+1, model), nil
 }
 
 // Json takes an error and marshals it into
-// a JSON byte slice.
+// a JSON byte slice using the DefaultEncoder.
 //
-// If err is a wrapped error, the inner error
-// will be represented in the "error" field.
-// Otherwise, the result of Error() on err will
-// be represented in the "error" field. This
-// does only apply though if exposeError is
-// passed as true. By default, "error" will
-// contain no information about the actual
-// error to prevent unintended information
-// leakage.
+// The error is transformed into an
+// ErrorResponseModel containing the code, the
+// public message, the details and the given
+// statusCode. Inner errors are not represented
+// by default to prevent unintended information
+// leakage. In debug mode, the text of all errors
+// in the chain and the call stack are added in
+// the "Debug" field. See Encoder for more
+// information.
 //
-// If the err implements HasCode, the code
-// of the error will be represented in the
-// "code" field of the JSON result.
-//
-// If the err implements HasMessage, the
-// JSON object will contain it as "message"
-// field, if present.
+// If statusCode is 0, the status code registered
+// for the code of the error in the DefaultRegistry
+// is used, if available.
 //
 // When the JSON marshal fails, an error is
 // returned.
 func Json(err error, statusCode int) ([]byte, error) {
-	model := Cast(err).ToResponseModel(statusCode)
-
-	data, jErr := json.MarshalIndent(model, "", "  ")
-	if jErr != nil {
-		return nil, jErr
-	}
-
-	return data, nil
+	return DefaultEncoder.Json(err, statusCode)
 }
 
 // MustJson is an alias for Json but panics when
@@ -1,6 +1,9 @@
 package elk_test
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/studio-b12/elk"
@@ -21,6 +24,34 @@ type refError struct{}
 
 func (t *refError) Error() string { return "refError" }
 
+type asError struct{}
+
+func (asError) Error() string { return "asError" }
+
+func (asError) As(target any) bool {
+	if s, ok := target.(*stringError); ok {
+		*s = "converted"
+		return true
+	}
+	return false
+}
+
+func TestAs(t *testing.T) {
+	s, ok := elk.As[stringError](fmt.Errorf("wrapped: %w", asError{}))
+	assert.True(t, ok)
+	assert.Equal(t, stringError("converted"), s)
+
+	s, ok = elk.As[stringError](errors.Join(structError{"test"}, stringError("joined")))
+	assert.True(t, ok)
+	assert.Equal(t, stringError("joined"), s)
+
+	_, ok = elk.As[*refError](asError{})
+	assert.False(t, ok)
+
+	_, ok = elk.As[stringError](nil)
+	assert.False(t, ok)
+}
+
 func TestIsTypeOf(t *testing.T) {
 	assert.True(t,
 		elk.IsOfType[stringError](stringError("test")))
@@ -30,6 +61,8 @@ func TestIsTypeOf(t *testing.T) {
 		elk.IsOfType[stringError](elk.InnerError{Inner: stringError("test")}))
 	assert.True(t,
 		elk.IsOfType[*refError](elk.InnerError{Inner: &refError{}}))
+	assert.True(t,
+		elk.IsOfType[structError](errors.Join(stringError("test"), structError{"test"})))
 
 	assert.False(t,
 		elk.IsOfType[structError](stringError("test")))
@@ -46,3 +79,53 @@ type foo interface {
 type fooImpl struct{}
 
 func (fooImpl) Foo() {}
+
+func TestContainsCode(t *testing.T) {
+	const (
+		ErrCode      = elk.ErrorCode("some-error-code")
+		ErrOtherCode = elk.ErrorCode("some-other-error-code")
+	)
+
+	var err error = elk.NewError(ErrCode)
+	err = fmt.Errorf("wrapped: %w", err)
+	err = elk.Wrap(ErrOtherCode, err)
+
+	assert.False(t, elk.IsCode(err, ErrCode))
+	assert.True(t, elk.ContainsCode(err, ErrCode))
+	assert.True(t, elk.ContainsCode(err, ErrOtherCode))
+	assert.False(t, elk.ContainsCode(err, elk.CodeUnexpected))
+
+	err = errors.Join(errors.New("some error"), fmt.Errorf("wrapped: %w", err))
+	assert.True(t, elk.ContainsCode(err, ErrCode))
+	assert.False(t, elk.ContainsCode(nil, ErrCode))
+}
+
+func TestDecodeJson(t *testing.T) {
+	const ErrCode = elk.ErrorCode("some-error-code")
+
+	err := elk.NewErrorWithDetails(ErrCode, map[string]int{"foo": 1}, "some message")
+	data := elk.MustJson(err, 400)
+
+	decoded, dErr := elk.DecodeJson(data)
+	assert.Nil(t, dErr)
+	assert.Equal(t, ErrCode, decoded.Code())
+	assert.Equal(t, "some message", decoded.Message())
+	assert.Equal(t, `{"foo":1}`, string(decoded.Details().(json.RawMessage)))
+
+	model, ok := elk.As[*elk.ErrorResponseModel](decoded)
+	assert.True(t, ok)
+	assert.Equal(t, 400, model.Status)
+
+	reencoded := elk.MustJson(decoded, 400)
+	assert.Equal(t, string(data), string(reencoded))
+
+	decoded, dErr = elk.DecodeJson([]byte(`{"Code": "foo", "Details": null}`))
+	assert.Nil(t, dErr)
+	assert.Equal(t, nil, decoded.Details())
+
+	_, dErr = elk.DecodeJson([]byte(`{"foo": "bar"}`))
+	assert.True(t, dErr != nil)
+
+	_, dErr = elk.DecodeJson([]byte(`invalid`))
+	assert.True(t, dErr != nil)
+}
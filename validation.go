@@ -0,0 +1,131 @@
+package elk
+
+import (
+	"strconv"
+)
+
+// CodeValidationFailed is the ErrorCode of Errors
+// created from a Validation.
+const CodeValidationFailed = ErrorCode("validation-failed")
+
+// ValidationEntry describes a single problem found
+// during the validation of a field.
+type ValidationEntry struct {
+	Field   string         // The path of the field, i.e. `items[3].price`
+	Code    ErrorCode      // The code of the problem
+	Message string         `json:",omitempty"` // An optional message describing the problem
+	Params  map[string]any `json:",omitempty"` // Optional parameters of the problem, i.e. limits
+}
+
+// Validation collects ValidationEntries of field-level
+// problems and turns them into a single Error.
+//
+// Nested validations for sub-objects and slice elements
+// can be created via Field and Index. They share their
+// entries with the validation they have been created
+// from and prefix the field paths of added entries
+// accordingly.
+//
+//	v := elk.NewValidation()
+//	v.Check(req.Name != "", "name", ErrRequired, "name is required")
+//	for i, item := range req.Items {
+//		iv := v.Field("items").Index(i)
+//		iv.Check(item.Price >= 0, "price", ErrNegative, "price must not be negative")
+//	}
+//	if err := v.Err(); err != nil {
+//		return err
+//	}
+type Validation struct {
+	path    string
+	entries *[]ValidationEntry
+}
+
+// NewValidation returns a new empty Validation.
+func NewValidation() *Validation {
+	return &Validation{
+		entries: new([]ValidationEntry),
+	}
+}
+
+// Field returns a nested Validation for the field
+// with the given name.
+func (t *Validation) Field(name string) *Validation {
+	return &Validation{
+		path:    t.fieldPath(name),
+		entries: t.entries,
+	}
+}
+
+// Index returns a nested Validation for the element
+// at the given index.
+func (t *Validation) Index(i int) *Validation {
+	return &Validation{
+		path:    t.path + "[" + strconv.Itoa(i) + "]",
+		entries: t.entries,
+	}
+}
+
+// Add adds an entry for the given field. If field is
+// empty, the entry refers to the path of the
+// Validation itself.
+func (t *Validation) Add(field string, code ErrorCode, message string) {
+	t.AddParams(field, code, message, nil)
+}
+
+// AddParams adds an entry for the given field with the
+// given parameters. If field is empty, the entry refers
+// to the path of the Validation itself.
+func (t *Validation) AddParams(field string, code ErrorCode, message string, params map[string]any) {
+	*t.entries = append(*t.entries, ValidationEntry{
+		Field:   t.fieldPath(field),
+		Code:    code,
+		Message: message,
+		Params:  params,
+	})
+}
+
+// Check adds an entry for the given field if ok is
+// false. ok is returned.
+func (t *Validation) Check(ok bool, field string, code ErrorCode, message string) bool {
+	if !ok {
+		t.Add(field, code, message)
+	}
+	return ok
+}
+
+// Entries returns all collected entries.
+func (t *Validation) Entries() []ValidationEntry {
+	return *t.entries
+}
+
+// Len returns the number of collected entries.
+func (t *Validation) Len() int {
+	return len(*t.entries)
+}
+
+// Err returns nil if no entries have been collected.
+// Otherwise, an Error with the code CodeValidationFailed
+// is returned with the collected entries as details.
+func (t *Validation) Err() error {
+	if len(*t.entries) == 0 {
+		return nil
+	}
+
+	entries := make([]ValidationEntry, len(*t.entries))
+	copy(entries, *t.entries)
+
+	e := newError(1, CodeValidationFailed, []string{"validation failed"})
+	e.details = &detailsBox{value: entries}
+
+	return e
+}
+
+func (t *Validation) fieldPath(name string) string {
+	if t.path == "" {
+		return name
+	}
+	if name == "" {
+		return t.path
+	}
+	return t.path + "." + name
+}
@@ -0,0 +1,41 @@
+package elk
+
+import (
+	"testing"
+
+	"github.com/studio-b12/elk/internal/assert"
+)
+
+func TestValidation(t *testing.T) {
+	const ErrCode = ErrorCode("some-error-code")
+
+	v := NewValidation()
+	assert.Nil(t, v.Err())
+
+	assert.True(t, v.Check(true, "foo", ErrCode, "some message"))
+	assert.Equal(t, 0, v.Len())
+
+	assert.False(t, v.Check(false, "foo", ErrCode, "some message"))
+
+	sv := v.Field("bar")
+	sv.Add("", ErrCode, "")
+	sv.Field("baz").Index(2).Index(3).Add("qux", ErrCode, "")
+	v.Index(1).Add("", ErrCode, "")
+
+	paths := []string{"foo", "bar", "bar.baz[2][3].qux", "[1]"}
+	assert.Equal(t, len(paths), v.Len())
+	assert.Equal(t, len(paths), sv.Len())
+	for i, entry := range v.Entries() {
+		assert.Equal(t, paths[i], entry.Field)
+	}
+
+	err := v.Err()
+	e := Cast(err)
+	assert.Equal(t, CodeValidationFailed, e.Code())
+
+	entries := e.Details().([]ValidationEntry)
+	assert.Equal(t, len(paths), len(entries))
+
+	v.Add("other", ErrCode, "")
+	assert.Equal(t, len(paths), len(entries))
+}
@@ -0,0 +1,286 @@
+package elk
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+)
+
+// DefaultMaxUnwrapDepth is the maximum number of
+// unwrapping steps followed when traversing wrapped
+// errors, unless configured otherwise.
+const DefaultMaxUnwrapDepth = 100
+
+var maxUnwrapDepth atomic.Int64
+
+func init() {
+	maxUnwrapDepth.Store(DefaultMaxUnwrapDepth)
+}
+
+// SetMaxUnwrapDepth sets the maximum number of unwrapping
+// steps followed by all functions of the package which
+// traverse wrapped errors, like UnwrapFull, IsOfType, Walk,
+// Cast and the formatters of Error. Errors deeper than that
+// are ignored and formatted outputs mark the truncation.
+// When depth is not positive, DefaultMaxUnwrapDepth is used.
+//
+// Independent of the depth, the traversal stops at errors
+// which are equal to one of the errors they are wrapped
+// by, so errors whose Unwrap methods form a cycle do not
+// cause endless loops.
+func SetMaxUnwrapDepth(depth int) {
+	if depth <= 0 {
+		depth = DefaultMaxUnwrapDepth
+	}
+	maxUnwrapDepth.Store(int64(depth))
+}
+
+// truncation describes why the traversal of
+// wrapped errors has been stopped early.
+type truncation struct {
+	// cycle is set when an error has been
+	// wrapped by an equal error.
+	cycle bool
+
+	// maxDepth is set to the maximum unwrap
+	// depth when it has been exceeded.
+	maxDepth int
+}
+
+func (t truncation) truncated() bool {
+	return t.cycle || t.maxDepth > 0
+}
+
+func (t truncation) String() string {
+	if t.cycle {
+		return "cycle detected"
+	}
+	return fmt.Sprintf("maximum unwrap depth of %d exceeded", t.maxDepth)
+}
+
+// WalkFunc is called by Walk for each visited error.
+//
+// depth is the number of unwrapping steps from the root
+// error to err. path contains, for each of these steps,
+// the index of the error in the list of errors returned
+// by the Unwrap method of its parent. For errors which
+// only wrap a single error, the index is always 0. The
+// path slice is reused and must not be retained after
+// the call.
+//
+// When false is returned, the walk is stopped.
+type WalkFunc func(err error, depth int, path []int) bool
+
+// Walk traverses the tree of errors wrapped by err in
+// depth-first pre-order, starting with err itself, and
+// calls fn for each visited error.
+//
+// In contrast to errors.Unwrap, errors implementing
+// `Unwrap() []error`, like errors created with
+// errors.Join or MultiErrors, are traversed as well.
+//
+// Errors deeper than the depth set via SetMaxUnwrapDepth
+// and errors equal to one of the errors wrapping them
+// are not visited.
+func Walk(err error, fn WalkFunc) {
+	walkTree(err, fn, nil)
+}
+
+// walkTree implements Walk. If truncated is not nil, it is
+// called with the depth and path of each error which is not
+// visited because of the given truncation.
+func walkTree(err error, fn WalkFunc, truncated func(depth int, path []int, t truncation)) {
+	if err == nil {
+		return
+	}
+
+	w := walker{
+		fn:        fn,
+		truncated: truncated,
+		maxDepth:  int(maxUnwrapDepth.Load()),
+		path:      make([]int, 0, 8),
+		ancestors: make([]error, 0, 8),
+	}
+	w.walk(err)
+}
+
+type walker struct {
+	fn        WalkFunc
+	truncated func(depth int, path []int, t truncation)
+	maxDepth  int
+	path      []int
+	ancestors []error
+}
+
+func (t *walker) walk(err error) bool {
+	depth := len(t.path)
+
+	if depth > t.maxDepth {
+		t.truncate(truncation{maxDepth: t.maxDepth})
+		return true
+	}
+
+	if containsError(t.ancestors, err) {
+		t.truncate(truncation{cycle: true})
+		return true
+	}
+
+	if !t.fn(err, depth, t.path) {
+		return false
+	}
+
+	t.ancestors = append(t.ancestors, err)
+
+	for i, inner := range unwrapAll(err) {
+		if inner == nil {
+			continue
+		}
+
+		t.path = append(t.path, i)
+		ok := t.walk(inner)
+		t.path = t.path[:depth]
+
+		if !ok {
+			return false
+		}
+	}
+
+	t.ancestors = t.ancestors[:depth]
+
+	return true
+}
+
+func (t *walker) truncate(tr truncation) {
+	if t.truncated != nil {
+		t.truncated(len(t.path), t.path, tr)
+	}
+}
+
+// unwrapChain calls fn for err and each error in its chain
+// unwrapped via errors.Unwrap until fn returns false or the
+// chain ends. The chain is truncated at errors deeper than
+// the maximum unwrap depth and at errors equal to one of
+// the errors before them.
+func unwrapChain(err error, fn func(err error) bool) truncation {
+	maxDepth := int(maxUnwrapDepth.Load())
+
+	var buf [8]error
+	ancestors := buf[:0]
+
+	for depth := 0; err != nil; depth++ {
+		if depth > maxDepth {
+			return truncation{maxDepth: maxDepth}
+		}
+
+		if containsError(ancestors, err) {
+			return truncation{cycle: true}
+		}
+
+		if !fn(err) {
+			break
+		}
+
+		ancestors = append(ancestors, err)
+		err = errors.Unwrap(err)
+	}
+
+	return truncation{}
+}
+
+// containsError reports whether errs contains an error
+// which is equal to err. Errors of types which are not
+// comparable are never considered equal.
+func containsError(errs []error, err error) bool {
+	typ := reflect.TypeOf(err)
+	for _, e := range errs {
+		if reflect.TypeOf(e) == typ && equalErrors(e, err) {
+			return true
+		}
+	}
+	return false
+}
+
+func equalErrors(a, b error) (equal bool) {
+	// Comparing errors of the same type panics when
+	// the type or any contained value is not comparable.
+	defer func() {
+		if recover() != nil {
+			equal = false
+		}
+	}()
+	return a == b
+}
+
+// unwrapAll returns the errors directly wrapped by err.
+func unwrapAll(err error) []error {
+	switch uErr := err.(type) {
+	case interface{ Unwrap() error }:
+		if inner := uErr.Unwrap(); inner != nil {
+			return []error{inner}
+		}
+	case interface{ Unwrap() []error }:
+		return uErr.Unwrap()
+	}
+	return nil
+}
+
+// Find returns the first error in the tree of err
+// in the order of Walk which is of type T.
+func Find[T error](err error) (t T, ok bool) {
+	Walk(err, func(err error, _ int, _ []int) bool {
+		t, ok = err.(T)
+		return !ok
+	})
+	return t, ok
+}
+
+// FindAll returns all errors in the tree of err
+// in the order of Walk which are of type T.
+func FindAll[T error](err error) []T {
+	var res []T
+	Walk(err, func(err error, _ int, _ []int) bool {
+		if t, ok := err.(T); ok {
+			res = append(res, t)
+		}
+		return true
+	})
+	return res
+}
+
+// Codes returns the distinct codes of all errors in
+// the tree of err which implement HasCode in the order
+// of Walk.
+func Codes(err error) []ErrorCode {
+	var codes []ErrorCode
+	Walk(err, func(err error, _ int, _ []int) bool {
+		c, ok := err.(HasCode)
+		if !ok {
+			return true
+		}
+
+		code := c.Code()
+		for _, existing := range codes {
+			if existing == code {
+				return true
+			}
+		}
+
+		codes = append(codes, code)
+		return true
+	})
+	return codes
+}
+
+// Leaves returns all errors in the tree of err which
+// do not wrap any further errors in the order of Walk.
+func Leaves(err error) []error {
+	var leaves []error
+	Walk(err, func(err error, _ int, _ []int) bool {
+		if len(unwrapAll(err)) == 0 {
+			leaves = append(leaves, err)
+		}
+		return true
+	})
+	return leaves
+}
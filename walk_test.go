@@ -0,0 +1,244 @@
+package elk_test
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/studio-b12/elk"
+	"github.com/studio-b12/elk/internal/assert"
+)
+
+func TestWalk(t *testing.T) {
+	const (
+		ErrCodeA = elk.ErrorCode("walk-a")
+		ErrCodeB = elk.ErrorCode("walk-b")
+	)
+
+	leafA := errors.New("leaf a")
+	leafB := stringError("leaf b")
+	leafC := structError{"leaf c"}
+
+	err := elk.Wrap(ErrCodeA, errors.Join(
+		elk.Wrap(ErrCodeB, leafA),
+		fmt.Errorf("%w and %w", leafB, elk.Wrap(ErrCodeA, leafC)),
+	))
+
+	t.Run("order", func(t *testing.T) {
+		var texts []string
+		var depths []int
+		var paths []string
+
+		elk.Walk(err, func(err error, depth int, path []int) bool {
+			texts = append(texts, err.Error())
+			depths = append(depths, depth)
+			paths = append(paths, fmt.Sprint(path))
+			return true
+		})
+
+		assert.Equal(t, strings.Join([]string{
+			err.Error(),
+			err.Unwrap().Error(),
+			"leaf a",
+			"leaf a",
+			"leaf b and <walk-a> (leaf c)",
+			"leaf b",
+			"leaf c",
+			"leaf c",
+		}, "|"), strings.Join(texts, "|"))
+		assert.Equal(t, "[0 1 2 3 2 3 3 4]", fmt.Sprint(depths))
+		assert.Equal(t, "[] [0] [0 0] [0 0 0] [0 1] [0 1 0] [0 1 1] [0 1 1 0]",
+			strings.Join(paths, " "))
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		n := 0
+		elk.Walk(err, func(err error, _ int, _ []int) bool {
+			n++
+			return err != leafA
+		})
+		assert.Equal(t, 4, n)
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		elk.Walk(nil, func(error, int, []int) bool {
+			t.Fatal("must not be called")
+			return true
+		})
+	})
+
+	t.Run("find", func(t *testing.T) {
+		s, ok := elk.Find[stringError](err)
+		assert.True(t, ok)
+		assert.Equal(t, leafB, s)
+
+		_, ok = elk.Find[*refError](err)
+		assert.False(t, ok)
+
+		all := elk.FindAll[elk.Error](err)
+		assert.Equal(t, 3, len(all))
+		assert.Equal(t, ErrCodeA, all[0].Code())
+		assert.Equal(t, ErrCodeB, all[1].Code())
+		assert.Equal(t, ErrCodeA, all[2].Code())
+	})
+
+	t.Run("codes", func(t *testing.T) {
+		assert.Equal(t, "[walk-a walk-b]", fmt.Sprint(elk.Codes(err)))
+		assert.Equal(t, 0, len(elk.Codes(leafA)))
+	})
+
+	t.Run("leaves", func(t *testing.T) {
+		leaves := elk.Leaves(err)
+		assert.Equal(t, 3, len(leaves))
+		assert.True(t, leaves[0] == leafA)
+		assert.True(t, leaves[1] == error(leafB))
+		assert.True(t, leaves[2] == error(leafC))
+	})
+}
+
+func TestFormatVerboseTree(t *testing.T) {
+	err := elk.Wrap(elk.CodeUnexpected, errors.Join(
+		errors.New("first"),
+		errors.Join(errors.New("second"), errors.New("third")),
+	), "outer")
+
+	var lines []string
+	for _, line := range strings.Split(fmt.Sprintf("%#v", err), "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "github.com/") {
+			lines = append(lines, line)
+		}
+	}
+
+	assert.Equal(t, strings.Join([]string{
+		"<unexpected-error> outer",
+		"originated:",
+		"type:",
+		"  elk.Error",
+		"----------",
+		"2 wrapped errors",
+		"type:",
+		"  *errors.joinError",
+		"----------",
+		"  [0] first",
+		"  type:",
+		"    *errors.errorString",
+		"  ----------",
+		"  [1] 2 wrapped errors",
+		"  type:",
+		"    *errors.joinError",
+		"  ----------",
+		"    [0] second",
+		"    type:",
+		"      *errors.errorString",
+		"    ----------",
+		"    [1] third",
+		"    type:",
+		"      *errors.errorString",
+		"    ----------",
+		"",
+	}, "\n"), strings.Join(lines, "\n"))
+
+	lines = strings.Split(fmt.Sprintf("%#.2v", err), "\n")
+	assert.False(t, slices.ContainsFunc(lines, func(l string) bool {
+		return strings.Contains(l, "first")
+	}))
+}
+
+type cycleError struct {
+	next error
+}
+
+func (t *cycleError) Error() string { return "cycle" }
+func (t *cycleError) Unwrap() error { return t.next }
+
+type loopError struct{}
+
+func (loopError) Error() string { return "loop" }
+func (loopError) Unwrap() error { return loopError{} }
+
+type sliceError struct {
+	depth []int
+}
+
+func (t sliceError) Error() string { return "slice" }
+func (t sliceError) Unwrap() error { return sliceError{depth: append(t.depth, 0)} }
+
+func newCycle() (elk.Error, *cycleError) {
+	c := &cycleError{}
+	err := elk.Wrap(elk.CodeUnexpected, c, "outer")
+	c.next = err
+	return err, c
+}
+
+func TestUnwrapCycle(t *testing.T) {
+	err, c := newCycle()
+
+	assert.True(t, elk.UnwrapFull(err) == error(c))
+	assert.True(t, elk.UnwrapFull(loopError{}) == error(loopError{}))
+	assert.False(t, elk.IsOfType[*refError](err))
+	assert.False(t, elk.IsOfType[*refError](errors.Join(loopError{}, err)))
+
+	self := &cycleError{}
+	self.next = self
+	_, ok := elk.As[*elk.PanicError](self)
+	assert.False(t, ok)
+	found, ok := elk.As[elk.Error](c)
+	assert.True(t, ok)
+	assert.Equal(t, "outer", found.Message())
+	assert.False(t, elk.ContainsCode(err, elk.CodeValidationFailed))
+	assert.Equal(t, 1, len(elk.Leaves(errors.Join(errors.New("leaf"), err))))
+
+	n := 0
+	elk.Walk(err, func(error, int, []int) bool {
+		n++
+		return true
+	})
+	assert.Equal(t, 2, n)
+
+	cast := elk.Cast(&cycleError{next: err})
+	assert.Equal(t, elk.CodeUnexpected, cast.Code())
+	assert.Equal(t, "", cast.Message())
+	assert.Equal(t, "outer", elk.WrapCopyCode(elk.Wrap(elk.CodeUnexpected, c)).Message())
+
+	assert.True(t, strings.HasSuffix(fmt.Sprintf("%+v", err),
+		"truncated:\n  cycle detected\ninner error:\n  cycle"))
+	assert.True(t, strings.HasSuffix(fmt.Sprintf("%+#v", err),
+		"caused by: *elk_test.cycleError: cycle\ncaused by: ... cycle detected\n"))
+	assert.True(t, strings.HasSuffix(fmt.Sprintf("%#v", err),
+		"*elk_test.cycleError\n----------\n... cycle detected\n"))
+	assert.True(t, strings.HasSuffix(fmt.Sprintf("%#v", elk.Wrap(elk.CodeUnexpected, errors.Join(loopError{}))),
+		"    elk_test.loopError\n  ----------\n  ... cycle detected\n"))
+
+	data, mErr := elk.MarshalDiagnostic(err)
+	assert.Nil(t, mErr)
+	assert.True(t, strings.HasSuffix(string(data), `"truncated":"cycle detected"}`))
+}
+
+func TestUnwrapMaxDepth(t *testing.T) {
+	elk.SetMaxUnwrapDepth(10)
+	t.Cleanup(func() { elk.SetMaxUnwrapDepth(0) })
+
+	// sliceError is not comparable, so its endless
+	// chain is only stopped by the maximum depth.
+	last, ok := elk.UnwrapFull(sliceError{}).(sliceError)
+	assert.True(t, ok)
+	assert.Equal(t, 10, len(last.depth))
+
+	assert.False(t, elk.IsOfType[*refError](sliceError{}))
+	assert.Equal(t, 11, len(elk.FindAll[sliceError](sliceError{})))
+
+	err := elk.Wrap(elk.CodeUnexpected, sliceError{}, "outer")
+	assert.True(t, strings.Contains(fmt.Sprintf("%+v", err),
+		"truncated:\n  maximum unwrap depth of 10 exceeded\n"))
+	assert.True(t, strings.HasSuffix(fmt.Sprintf("%+#v", err),
+		"caused by: ... maximum unwrap depth of 10 exceeded\n"))
+	assert.True(t, strings.HasSuffix(fmt.Sprintf("%#v", err),
+		"----------\n... maximum unwrap depth of 10 exceeded\n"))
+	assert.False(t, strings.Contains(fmt.Sprintf("%#.5v", err), "exceeded"))
+
+	elk.SetMaxUnwrapDepth(0)
+	assert.Equal(t, elk.DefaultMaxUnwrapDepth,
+		len(elk.UnwrapFull(sliceError{}).(sliceError).depth))
+}